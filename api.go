@@ -21,6 +21,8 @@ import (
 	"github.com/pquerna/cachecontrol/cacheobject"
 
 	"net/http"
+	"net/textproto"
+	"net/url"
 	"time"
 )
 
@@ -28,6 +30,105 @@ type Options struct {
 	// Set to True for a private cache, which is not shared among users (eg, in a browser)
 	// Set to False for a "shared" cache, which is more common in a server context.
 	PrivateCache bool
+
+	// Set to True to not apply heuristic freshness to responses for requests
+	// with a query string, unless the response has explicit freshness.
+	// See http://tools.ietf.org/html/rfc7234#section-4.2.2
+	DisableHeuristicForQuery bool
+
+	// Set to True to treat responses without explicit freshness information as uncachable,
+	// even when their status code is cachable by default, eg, for sensitive APIs.
+	// Heuristic freshness is never applied.
+	DefaultToUncachable bool
+
+	// Set to True to cache responses to OPTIONS requests (eg, CORS preflights)
+	// which include explicit freshness information.
+	CachableOPTIONS bool
+
+	// Set to a non-zero duration to cap how long any response is considered fresh.
+	// When the cap is applied, it is reported with the informational reason
+	// cacheobject.ReasonResponseFreshnessCapped, eg, in Decision.InfoReasons.
+	MaxFreshnessLifetime time.Duration
+
+	// Set to a non-zero duration to report a response Date header further than this in
+	// the future with the informational reason cacheobject.ReasonResponseClockSkew.
+	ClockSkewThreshold time.Duration
+
+	// Set to a non-zero duration to report responses whose max-age and Expires header differ
+	// by more than this with the informational reason cacheobject.ReasonResponseMaxAgeExpiresDisagree.
+	// max-age is always used.
+	MaxAgeExpiresThreshold time.Duration
+
+	// Set to request headers, like Cookie, which make a response uncachable when present,
+	// regardless of the response's directives.
+	UncachableRequestHeaders []string
+
+	// Set to True to treat a response Pragma: no-cache as Cache-Control: no-cache when the
+	// response has no Cache-Control header, reported with the informational reason
+	// cacheobject.ReasonResponsePragmaNoCache.
+	ResponsePragmaNoCache bool
+
+	// Set to a non-zero size to report responses with a larger Content-Length with
+	// cacheobject.ReasonResponseBodyTooLarge. Responses without a body, like a
+	// 204 No Content or 304 Not Modified, are never too large.
+	MaxCachableBodyBytes int64
+
+	// Set to True for a shared cache to report responses with a Content-Encoding, like gzip,
+	// whose Vary header doesn't include Accept-Encoding, with
+	// cacheobject.ReasonResponseCompressedWithoutVary.
+	WarnMissingVaryOnContentEncoding bool
+
+	// Set to True to report responses without an ETag or Last-Modified header, which can't
+	// be revalidated with a conditional request, with cacheobject.ReasonResponseNoValidator.
+	RequireValidator bool
+
+	// Set to True for a shared cache to report responses whose Vary header includes Cookie,
+	// which differs for every user, with cacheobject.ReasonResponseVaryCookie.
+	TreatVaryCookieAsUncachable bool
+
+	// Set to True to treat 206 Partial Content responses with a multipart/byteranges
+	// body, which carry several ranges, as uncachable, eg, for a simple range cache.
+	TreatMultipartRangesAsUncachable bool
+
+	// The Cache-Control extension directives the cache implements, eg, for a cache which
+	// only implements RFC 7234, an empty map. When not nil, responses with any other
+	// extension directive, like stale-while-revalidate, are reported with the informational
+	// reason cacheobject.ReasonResponseUnknownDirective.
+	KnownExtensions map[string]bool
+
+	// Set to True to parse the response Cache-Control header leniently, with
+	// cacheobject.ParseResponseCacheControlLenient, eg, so an invalid max-age is ignored
+	// rather than returning an error. With EmitInfoReasons, it is reported with
+	// cacheobject.ReasonResponseInvalidDirectiveIgnored.
+	LenientDirectives bool
+
+	// When non-zero, the expiration time of responses with a status code cachable by
+	// default, but with neither explicit freshness nor a Last-Modified header, eg, a
+	// bare 200, instead of the zero time.
+	DefaultHeuristicTTL time.Duration
+
+	// Set to True to also return informational reasons, which explain why a response was
+	// cachable, like cacheobject.ReasonInfoPOSTCachableWithFreshness. See Reason.IsInfo.
+	// They are never returned by CachableResponse, only separately, eg, in Decision.InfoReasons.
+	EmitInfoReasons bool
+
+	// Set to receive a human readable trace of how the reasons and expiration time were
+	// determined, eg, "using s-maxage=60", to explain cache decisions to developers.
+	Trace func(format string, args ...interface{})
+
+	// Set to how long before a response must no longer be served a background
+	// revalidation should be triggered, see RevalidateAt.
+	RevalidationLeadTime time.Duration
+
+	// Set to classify if responses to a request may be cached based on more than the
+	// request method, eg, for gRPC unary calls which are POSTs. When the classifier
+	// returns cacheobject.MethodCacheabilityDefault, the request method is used.
+	MethodClassifier func(req *http.Request) cacheobject.MethodCacheability
+
+	// Set to determine the effective request URI, eg, from X-Forwarded-Host behind a reverse
+	// proxy. It is matched against the Content-Location of responses to POST requests, and
+	// used by InvalidationTargets. When not set, the request URL is used.
+	EffectiveURI func(req *http.Request) string
 }
 
 // Given an HTTP Request, the future Status Code, and an ResponseWriter,
@@ -36,7 +137,7 @@ func CachableResponseWriter(req *http.Request,
 	statusCode int,
 	resp http.ResponseWriter,
 	opts Options) ([]cacheobject.Reason, time.Time, error) {
-	return cacheobject.UsingRequestResponse(req, statusCode, resp.Header(), opts.PrivateCache)
+	return cachable(req, statusCode, resp.Header(), opts)
 }
 
 // Given an HTTP Request and Response, determine the possible reasons a response SHOULD NOT
@@ -44,5 +145,123 @@ func CachableResponseWriter(req *http.Request,
 func CachableResponse(req *http.Request,
 	resp *http.Response,
 	opts Options) ([]cacheobject.Reason, time.Time, error) {
-	return cacheobject.UsingRequestResponse(req, resp.StatusCode, resp.Header, opts.PrivateCache)
+	return cachable(req, resp.StatusCode, resp.Header, opts)
+}
+
+// Given a request method and headers, and response status code and headers, from a source
+// other than net/http, determine the possible reasons a response SHOULD NOT be cached.
+//
+// Header names don't need to be canonical, eg, `cache-control` is found as `Cache-Control`.
+func CachableHeaders(method string,
+	reqHeaders map[string][]string,
+	statusCode int,
+	respHeaders map[string][]string,
+	opts Options) ([]cacheobject.Reason, time.Time, error) {
+	req := &http.Request{
+		Method: method,
+		Header: canonicalHeader(reqHeaders),
+	}
+	return cachable(req, statusCode, canonicalHeader(respHeaders), opts)
+}
+
+// the effective request URI: http://tools.ietf.org/html/rfc7230#section-5.5
+func effectiveURI(req *http.Request, opts Options) *url.URL {
+	if opts.EffectiveURI != nil {
+		if u, err := url.Parse(opts.EffectiveURI(req)); err == nil {
+			return u
+		}
+	}
+
+	if req.URL == nil || req.URL.Host != "" || req.Host == "" {
+		return req.URL
+	}
+
+	// server side requests only carry the path
+	u := *req.URL
+	u.Host = req.Host
+	u.Scheme = "http"
+	if req.TLS != nil {
+		u.Scheme = "https"
+	}
+	return &u
+}
+
+func canonicalHeader(headers map[string][]string) http.Header {
+	h := make(http.Header, len(headers))
+	for k, v := range headers {
+		key := textproto.CanonicalMIMEHeaderKey(k)
+		h[key] = append(h[key], v...)
+	}
+	return h
+}
+
+func cachable(req *http.Request,
+	statusCode int,
+	respHeaders http.Header,
+	opts Options) ([]cacheobject.Reason, time.Time, error) {
+	rv, err := evaluate(req, statusCode, respHeaders, opts)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return rv.OutReasons, rv.OutExpirationTime, nil
+}
+
+// evaluates a response, returning the informational reasons separately from the reasons
+// it should not be cached, which are all that CachableResponse returns.
+func evaluate(req *http.Request,
+	statusCode int,
+	respHeaders http.Header,
+	opts Options) (*cacheobject.ObjectResults, error) {
+	var obj *cacheobject.Object
+	var err error
+	if opts.LenientDirectives {
+		// NowUTC is left zero, so the current time is used when the object is evaluated.
+		obj, err = cacheobject.ObjectFromExchangeLenient(req, statusCode, respHeaders, time.Time{})
+	} else {
+		obj, err = cacheobject.NewObject(req, statusCode, respHeaders, opts.PrivateCache)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	obj.CacheIsPrivate = opts.PrivateCache
+	obj.DisableHeuristicForQuery = opts.DisableHeuristicForQuery
+	obj.DefaultToUncachable = opts.DefaultToUncachable
+	obj.CachableOPTIONS = opts.CachableOPTIONS
+	obj.MaxFreshnessLifetime = opts.MaxFreshnessLifetime
+	obj.ClockSkewThreshold = opts.ClockSkewThreshold
+	obj.MaxAgeExpiresThreshold = opts.MaxAgeExpiresThreshold
+	obj.UncachableRequestHeaders = opts.UncachableRequestHeaders
+	obj.ResponsePragmaNoCache = opts.ResponsePragmaNoCache
+	obj.MaxCachableBodyBytes = opts.MaxCachableBodyBytes
+	obj.WarnMissingVaryOnContentEncoding = opts.WarnMissingVaryOnContentEncoding
+	obj.RequireValidator = opts.RequireValidator
+	obj.TreatVaryCookieAsUncachable = opts.TreatVaryCookieAsUncachable
+	obj.TreatMultipartRangesAsUncachable = opts.TreatMultipartRangesAsUncachable
+	obj.KnownExtensions = opts.KnownExtensions
+	obj.DefaultHeuristicTTL = opts.DefaultHeuristicTTL
+	obj.EmitInfoReasons = opts.EmitInfoReasons
+	obj.Trace = opts.Trace
+
+	if req != nil {
+		obj.ReqURL = effectiveURI(req, opts)
+	}
+
+	if opts.MethodClassifier != nil && req != nil {
+		obj.ReqMethodCacheability = opts.MethodClassifier(req)
+	}
+
+	rv := cacheobject.ObjectResults{}
+
+	cacheobject.CachableObject(obj, &rv)
+	if rv.OutErr != nil {
+		return nil, rv.OutErr
+	}
+
+	cacheobject.ExpirationObject(obj, &rv)
+	if rv.OutErr != nil {
+		return nil, rv.OutErr
+	}
+
+	return &rv, nil
 }
@@ -25,6 +25,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -109,3 +110,615 @@ func TestResponseWriter(t *testing.T) {
 	require.Len(t, reasons, 0)
 	require.Equal(t, time.Time{}, expires)
 }
+
+func TestCachableResponseDisableHeuristicForQuery(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/search?q=cache", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+	}
+	res.Header.Set("Last-Modified",
+		time.Now().UTC().Add(time.Duration(time.Hour*-5)).Format(http.TimeFormat))
+
+	opts := Options{}
+	reasons, expires, err := CachableResponse(req, res, opts)
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+	require.False(t, expires.IsZero())
+
+	opts.DisableHeuristicForQuery = true
+	reasons, expires, err = CachableResponse(req, res, opts)
+	require.NoError(t, err)
+	require.Len(t, reasons, 1)
+	require.Equal(t, reasons[0], cacheobject.ReasonResponseUncachableByDefault)
+	require.Equal(t, time.Time{}, expires)
+}
+
+func TestCachableResponseMaxFreshnessLifetime(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+	}
+	res.Header.Set("Cache-Control", "max-age=86400")
+
+	opts := Options{MaxFreshnessLifetime: time.Hour}
+	reasons, expires, err := CachableResponse(req, res, opts)
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+	require.WithinDuration(t, time.Now().UTC().Add(time.Hour), expires, 10*time.Second)
+
+	d, err := Decide(req, res, opts)
+	require.NoError(t, err)
+	require.Equal(t, []cacheobject.Reason{cacheobject.ReasonResponseFreshnessCapped}, d.InfoReasons)
+}
+
+func TestCachableResponseMethodClassifier(t *testing.T) {
+	req, err := http.NewRequest("POST", "http://example.com/helloworld.Greeter/SayHello", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+	}
+
+	opts := Options{}
+	reasons, _, err := CachableResponse(req, res, opts)
+	require.NoError(t, err)
+	require.Len(t, reasons, 1)
+	require.Equal(t, reasons[0], cacheobject.ReasonRequestMethodPOST)
+
+	opts.MethodClassifier = func(req *http.Request) cacheobject.MethodCacheability {
+		if strings.HasPrefix(req.URL.Path, "/helloworld.Greeter/") {
+			return cacheobject.MethodCacheabilityCachable
+		}
+		return cacheobject.MethodCacheabilityDefault
+	}
+	reasons, _, err = CachableResponse(req, res, opts)
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+}
+
+func TestCachableResponseMethodNotAllowed(t *testing.T) {
+	req, res := roundTrip(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", "GET, HEAD")
+		w.Header().Set("Last-Modified",
+			time.Now().UTC().Add(time.Duration(time.Hour*-5)).Format(http.TimeFormat))
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	})
+
+	opts := Options{}
+	reasons, expires, err := CachableResponse(req, res, opts)
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+	require.WithinDuration(t,
+		time.Now().UTC().Add(time.Duration(float64(time.Hour)*0.5)),
+		expires,
+		10*time.Second)
+}
+
+func TestCachableResponseUncachableRequestHeaders(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+	}
+	res.Header.Set("Cache-Control", "public, max-age=60")
+
+	opts := Options{UncachableRequestHeaders: []string{"Cookie"}}
+	reasons, _, err := CachableResponse(req, res, opts)
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+
+	req.Header.Set("Cookie", "session=1")
+	reasons, _, err = CachableResponse(req, res, opts)
+	require.NoError(t, err)
+	require.Len(t, reasons, 1)
+	require.Equal(t, reasons[0], cacheobject.ReasonRequestUncachableHeader)
+}
+
+func TestCachableResponsePragmaNoCache(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+	}
+	res.Header.Set("Pragma", "no-cache")
+
+	reasons, _, err := CachableResponse(req, res, Options{})
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+
+	reasons, _, err = CachableResponse(req, res, Options{ResponsePragmaNoCache: true})
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+
+	d, err := Decide(req, res, Options{ResponsePragmaNoCache: true})
+	require.NoError(t, err)
+	require.Equal(t, []cacheobject.Reason{cacheobject.ReasonResponsePragmaNoCache}, d.InfoReasons)
+}
+
+func TestCachableResponsePragmaNoCacheWithCacheControl(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+	}
+	res.Header.Set("Pragma", "no-cache")
+	res.Header.Set("Cache-Control", "max-age=60")
+
+	reasons, _, err := CachableResponse(req, res, Options{ResponsePragmaNoCache: true})
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+}
+
+func TestCachableResponseSMaxAgeOnlyPrivateCache(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+
+	// 201 Created is not cachable by default, so only s-maxage could make it cachable.
+	res := &http.Response{
+		StatusCode: http.StatusCreated,
+		Header:     http.Header{},
+	}
+	res.Header.Set("Cache-Control", "s-maxage=300")
+
+	reasons, expires, err := CachableResponse(req, res, Options{PrivateCache: true})
+	require.NoError(t, err)
+	require.Len(t, reasons, 1)
+	require.Equal(t, reasons[0], cacheobject.ReasonResponseUncachableByDefault)
+	require.True(t, expires.IsZero())
+
+	reasons, expires, err = CachableResponse(req, res, Options{})
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+	require.WithinDuration(t, time.Now().UTC().Add(time.Second*300), expires, 10*time.Second)
+}
+
+func TestCachableResponseDefaultToUncachable(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+	}
+	res.Header.Set("Last-Modified", time.Now().UTC().Add(time.Hour*-5).Format(http.TimeFormat))
+
+	reasons, expires, err := CachableResponse(req, res, Options{})
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+	require.False(t, expires.IsZero())
+
+	reasons, expires, err = CachableResponse(req, res, Options{DefaultToUncachable: true})
+	require.NoError(t, err)
+	require.Len(t, reasons, 1)
+	require.Equal(t, reasons[0], cacheobject.ReasonResponseUncachableByDefault)
+	require.True(t, expires.IsZero())
+}
+
+func TestCachableResponseDefaultToUncachableExplicitFreshness(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+	}
+	res.Header.Set("Cache-Control", "max-age=60")
+
+	reasons, expires, err := CachableResponse(req, res, Options{DefaultToUncachable: true})
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+	require.WithinDuration(t, time.Now().UTC().Add(time.Minute), expires, 10*time.Second)
+}
+
+func TestCachableResponseRequireValidator(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+
+	lastModified := time.Now().UTC().Add(time.Hour * -5).Format(http.TimeFormat)
+	for _, headers := range []http.Header{
+		{"Etag": {`"v1"`}},
+		{"Last-Modified": {lastModified}},
+		{"Etag": {`"v1"`}, "Last-Modified": {lastModified}},
+	} {
+		res := &http.Response{
+			StatusCode: 200,
+			Header:     headers,
+		}
+		res.Header.Set("Cache-Control", "max-age=60")
+
+		reasons, _, err := CachableResponse(req, res, Options{RequireValidator: true})
+		require.NoError(t, err)
+		require.Len(t, reasons, 0)
+	}
+}
+
+func TestCachableResponseRequireValidatorMissing(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+	}
+	res.Header.Set("Cache-Control", "max-age=60")
+
+	reasons, _, err := CachableResponse(req, res, Options{})
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+
+	reasons, _, err = CachableResponse(req, res, Options{RequireValidator: true})
+	require.NoError(t, err)
+	require.Len(t, reasons, 1)
+	require.Equal(t, reasons[0], cacheobject.ReasonResponseNoValidator)
+}
+
+func TestCachableHeadersLowercase(t *testing.T) {
+	reqHeaders := map[string][]string{
+		"authorization": {"bearer random"},
+	}
+	respHeaders := map[string][]string{
+		"cache-control": {"public, max-age=60"},
+	}
+
+	reasons, expires, err := CachableHeaders("GET", reqHeaders, 200, respHeaders, Options{})
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+	require.WithinDuration(t, time.Now().UTC().Add(time.Minute), expires, 10*time.Second)
+
+	respHeaders["cache-control"] = []string{"max-age=60"}
+	reasons, _, err = CachableHeaders("GET", reqHeaders, 200, respHeaders, Options{})
+	require.NoError(t, err)
+	require.Len(t, reasons, 1)
+	require.Equal(t, reasons[0], cacheobject.ReasonRequestAuthorizationHeader)
+}
+
+func TestCachableHeadersMixedCase(t *testing.T) {
+	respHeaders := map[string][]string{
+		"cache-control": {"public"},
+		"CACHE-CONTROL": {"no-store"},
+	}
+
+	reasons, _, err := CachableHeaders("GET", nil, 200, respHeaders, Options{})
+	require.NoError(t, err)
+	require.Len(t, reasons, 1)
+	require.Equal(t, reasons[0], cacheobject.ReasonResponseNoStore)
+}
+
+func TestCachableResponsePOSTEffectiveURI(t *testing.T) {
+	// a server side request behind a reverse proxy
+	req, err := http.NewRequest("POST", "/submit", nil)
+	require.NoError(t, err)
+	req.Host = "backend:8080"
+	req.Header.Set("X-Forwarded-Host", "example.com")
+
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+	}
+	res.Header.Set("Cache-Control", "max-age=60")
+	res.Header.Set("Content-Location", "https://example.com/submit")
+
+	reasons, _, err := CachableResponse(req, res, Options{})
+	require.NoError(t, err)
+	require.Len(t, reasons, 1)
+	require.Equal(t, reasons[0], cacheobject.ReasonResponsePOSTContentLocationMismatch)
+
+	reasons, _, err = CachableResponse(req, res, Options{EffectiveURI: forwardedURI})
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+}
+
+func TestCachableResponsePublicBadRequest(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: http.StatusBadRequest,
+		Header:     http.Header{},
+	}
+	res.Header.Set("Cache-Control", "public, max-age=60")
+
+	reasons, expires, err := CachableResponse(req, res, Options{})
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+	require.WithinDuration(t, time.Now().UTC().Add(time.Minute), expires, 10*time.Second)
+}
+
+func TestCachableResponsePublicInternalServerError(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: http.StatusInternalServerError,
+		Header:     http.Header{},
+	}
+	res.Header.Set("Cache-Control", "public")
+
+	// public alone makes the response cachable, but it has no freshness
+	reasons, expires, err := CachableResponse(req, res, Options{})
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+	require.True(t, expires.IsZero())
+
+	res.Header.Set("Cache-Control", "public, max-age=60")
+	reasons, expires, err = CachableResponse(req, res, Options{})
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+	require.WithinDuration(t, time.Now().UTC().Add(time.Minute), expires, 10*time.Second)
+}
+
+func TestCachableResponseSMaxAgeShorterThanMaxAge(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+	}
+	res.Header.Set("Cache-Control", "s-maxage=60, max-age=3600")
+
+	reasons, expires, err := CachableResponse(req, res, Options{})
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+	require.WithinDuration(t, time.Now().UTC().Add(time.Second*60), expires, 10*time.Second)
+
+	reasons, expires, err = CachableResponse(req, res, Options{PrivateCache: true})
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+	require.WithinDuration(t, time.Now().UTC().Add(time.Second*3600), expires, 10*time.Second)
+}
+
+func TestCachableResponseVaryCookie(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+	}
+	res.Header.Set("Cache-Control", "max-age=60")
+	res.Header.Set("Vary", "Accept-Encoding, cookie")
+
+	reasons, _, err := CachableResponse(req, res, Options{})
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+
+	reasons, _, err = CachableResponse(req, res, Options{TreatVaryCookieAsUncachable: true})
+	require.NoError(t, err)
+	require.Len(t, reasons, 1)
+	require.Equal(t, reasons[0], cacheobject.ReasonResponseVaryCookie)
+
+	reasons, _, err = CachableResponse(req, res, Options{TreatVaryCookieAsUncachable: true, PrivateCache: true})
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+}
+
+func TestObjectFromExchangeMatchesCachableResponse(t *testing.T) {
+	now := time.Now().UTC()
+
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "bearer random")
+
+	for _, cacheControl := range []string{"max-age=60", "public, max-age=60", "private", "no-store"} {
+		res := &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{},
+		}
+		res.Header.Set("Cache-Control", cacheControl)
+		res.Header.Set("Date", now.Format(http.TimeFormat))
+		res.Header.Set("Age", "10")
+
+		expectedReasons, expectedExpires, err := CachableResponse(req, res, Options{})
+		require.NoError(t, err)
+
+		obj, err := cacheobject.ObjectFromExchange(req, res.StatusCode, res.Header, now)
+		require.NoError(t, err)
+		require.Equal(t, now, obj.NowUTC)
+		require.Equal(t, time.Second*10, obj.RespAgeHeader)
+
+		rv := cacheobject.ObjectResults{}
+		cacheobject.CachableObject(obj, &rv)
+		cacheobject.ExpirationObject(obj, &rv)
+		require.NoError(t, rv.OutErr)
+		require.Equal(t, expectedReasons, rv.OutReasons, cacheControl)
+		require.WithinDuration(t, expectedExpires, rv.OutExpirationTime, time.Second*2, cacheControl)
+	}
+}
+
+func TestCachableResponseAuthorizationMustRevalidate(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "bearer random")
+
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+	}
+	res.Header.Set("Cache-Control", "max-age=60, must-revalidate")
+
+	reasons, expires, err := CachableResponse(req, res, Options{})
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+	require.WithinDuration(t, time.Now().UTC().Add(time.Minute), expires, 10*time.Second)
+
+	// proxy-revalidate doesn't allow storing responses to authenticated requests.
+	res.Header.Set("Cache-Control", "max-age=60, proxy-revalidate")
+
+	reasons, _, err = CachableResponse(req, res, Options{})
+	require.NoError(t, err)
+	require.Equal(t, []cacheobject.Reason{cacheobject.ReasonRequestAuthorizationHeader}, reasons)
+}
+
+func TestCachableResponseDefaultHeuristicTTL(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+	}
+
+	opts := Options{}
+	reasons, expires, err := CachableResponse(req, res, opts)
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+	require.True(t, expires.IsZero())
+
+	opts.DefaultHeuristicTTL = time.Minute * 5
+	reasons, expires, err = CachableResponse(req, res, opts)
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+	require.WithinDuration(t, time.Now().UTC().Add(time.Minute*5), expires, 10*time.Second)
+
+	// the Last-Modified heuristic takes precedence.
+	res.Header.Set("Last-Modified", time.Now().UTC().Add(time.Hour*-10).Format(http.TimeFormat))
+	reasons, expires, err = CachableResponse(req, res, opts)
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+	require.WithinDuration(t, time.Now().UTC().Add(time.Hour), expires, 10*time.Second)
+}
+
+func TestCachableResponseDefaultHeuristicTTLUncachableStatus(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: 302,
+		Header:     http.Header{},
+	}
+
+	reasons, expires, err := CachableResponse(req, res, Options{DefaultHeuristicTTL: time.Minute * 5})
+	require.NoError(t, err)
+	require.Equal(t, []cacheobject.Reason{cacheobject.ReasonResponseUncachableByDefault}, reasons)
+	require.True(t, expires.IsZero())
+}
+
+func TestCachableResponseKnownExtensions(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+	}
+	res.Header.Set("Cache-Control", "max-age=60, stale-while-revalidate=30")
+
+	reasons, _, err := CachableResponse(req, res, Options{})
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+
+	opts := Options{KnownExtensions: map[string]bool{"stale-while-revalidate": true}}
+	reasons, _, err = CachableResponse(req, res, opts)
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+}
+
+func TestCachableResponseUnknownDirective(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+	}
+	res.Header.Set("Cache-Control", "max-age=60, stale-while-revalidate=30")
+
+	// a cache which only implements RFC 7234
+	opts := Options{KnownExtensions: map[string]bool{}}
+	d, err := Decide(req, res, opts)
+	require.NoError(t, err)
+	require.Len(t, d.Reasons, 0)
+	require.Equal(t, []cacheobject.Reason{cacheobject.ReasonResponseUnknownDirective}, d.InfoReasons)
+	require.WithinDuration(t, time.Now().UTC().Add(time.Minute), d.ExpirationTime, 10*time.Second)
+
+	res.Header.Set("Cache-Control", "max-age=60, community=UCI")
+	opts.KnownExtensions["stale-while-revalidate"] = true
+	d, err = Decide(req, res, opts)
+	require.NoError(t, err)
+	require.Equal(t, []cacheobject.Reason{cacheobject.ReasonResponseUnknownDirective}, d.InfoReasons)
+}
+
+func TestCachableResponsePrivateSetCookie(t *testing.T) {
+	req, res := roundTrip(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "private, max-age=60")
+		w.Header().Set("Set-Cookie", "session=abc123; Path=/; HttpOnly")
+		fmt.Fprintln(w, `{}`)
+	})
+
+	opts := Options{}
+	reasons, _, err := CachableResponse(req, res, opts)
+	require.NoError(t, err)
+	require.Equal(t, []cacheobject.Reason{cacheobject.ReasonResponsePrivate}, reasons)
+
+	opts.PrivateCache = true
+	reasons, expires, err := CachableResponse(req, res, opts)
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+	require.WithinDuration(t, time.Now().UTC().Add(time.Minute), expires, 10*time.Second)
+}
+
+func TestCachableResponsePrivateFieldSetCookie(t *testing.T) {
+	req, res := roundTrip(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", `private="Set-Cookie", max-age=60`)
+		w.Header().Set("Set-Cookie", "session=abc123; Path=/; HttpOnly")
+		fmt.Fprintln(w, `{}`)
+	})
+
+	opts := Options{}
+	reasons, _, err := CachableResponse(req, res, opts)
+	require.NoError(t, err)
+	require.Equal(t, []cacheobject.Reason{cacheobject.ReasonResponsePrivate}, reasons)
+
+	opts.PrivateCache = true
+	reasons, expires, err := CachableResponse(req, res, opts)
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+	require.WithinDuration(t, time.Now().UTC().Add(time.Minute), expires, 10*time.Second)
+}
+
+func TestCachableResponseInvalidMaxAgeExpires(t *testing.T) {
+	now := time.Now().UTC()
+
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+	}
+	res.Header.Set("Cache-Control", "public, max-age=abc")
+	res.Header.Set("Date", now.Format(http.TimeFormat))
+	res.Header.Set("Expires", now.Add(time.Hour).Format(http.TimeFormat))
+
+	_, _, err = CachableResponse(req, res, Options{})
+	require.Error(t, err)
+
+	reasons, expires, err := CachableResponse(req, res, Options{LenientDirectives: true})
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+	require.WithinDuration(t, now.Add(time.Hour), expires, 2*time.Second)
+
+	d, err := Decide(req, res, Options{LenientDirectives: true})
+	require.NoError(t, err)
+	require.Len(t, d.InfoReasons, 0)
+
+	d, err = Decide(req, res, Options{LenientDirectives: true, EmitInfoReasons: true})
+	require.NoError(t, err)
+	require.Equal(t, []cacheobject.Reason{cacheobject.ReasonResponseInvalidDirectiveIgnored}, d.InfoReasons)
+}
@@ -0,0 +1,83 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cachecontrol
+
+import (
+	"github.com/pquerna/cachecontrol/cacheobject"
+
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// A request and response to evaluate with CachableBatch, eg, from an access log.
+type Entry struct {
+	Method string
+	// The request URL. May be nil.
+	URL         *url.URL
+	ReqHeaders  http.Header
+	StatusCode  int
+	RespHeaders http.Header
+}
+
+// The result of evaluating an Entry, as returned by CachableResponse.
+type BatchResult struct {
+	Reasons []cacheobject.Reason
+	// Informational reasons, which don't prevent the response from being cached.
+	InfoReasons    []cacheobject.Reason
+	ExpirationTime time.Time
+	Err            error
+}
+
+// Given a list of requests and responses, determine the possible reasons each response
+// SHOULD NOT be cached, using the same Options for all of them.
+//
+// The results are in the same order as the entries. An error for one entry does not
+// prevent the others from being evaluated.
+func CachableBatch(entries []Entry, opts Options) []BatchResult {
+	results := make([]BatchResult, len(entries))
+	for i, entry := range entries {
+		reqHeaders := entry.ReqHeaders
+		if reqHeaders == nil {
+			reqHeaders = http.Header{}
+		}
+
+		respHeaders := entry.RespHeaders
+		if respHeaders == nil {
+			respHeaders = http.Header{}
+		}
+
+		req := &http.Request{
+			Method: entry.Method,
+			URL:    entry.URL,
+			Header: reqHeaders,
+		}
+
+		rv, err := evaluate(req, entry.StatusCode, respHeaders, opts)
+		if err != nil {
+			results[i] = BatchResult{Err: err}
+			continue
+		}
+		results[i] = BatchResult{
+			Reasons:        rv.OutReasons,
+			InfoReasons:    rv.OutInfoReasons,
+			ExpirationTime: rv.OutExpirationTime,
+		}
+	}
+	return results
+}
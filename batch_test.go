@@ -0,0 +1,70 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cachecontrol
+
+import (
+	"github.com/pquerna/cachecontrol/cacheobject"
+	"github.com/stretchr/testify/require"
+
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCachableBatch(t *testing.T) {
+	entries := []Entry{
+		{
+			Method:      "GET",
+			StatusCode:  200,
+			RespHeaders: http.Header{"Cache-Control": {"max-age=60"}},
+		},
+		{
+			Method:      "GET",
+			StatusCode:  200,
+			RespHeaders: http.Header{"Cache-Control": {"no-store"}},
+		},
+		{
+			Method:     "PUT",
+			StatusCode: 200,
+		},
+		{
+			Method:      "GET",
+			StatusCode:  200,
+			RespHeaders: http.Header{"Cache-Control": {"max-age=foo"}},
+		},
+	}
+
+	results := CachableBatch(entries, Options{})
+	require.Len(t, results, 4)
+
+	require.NoError(t, results[0].Err)
+	require.Len(t, results[0].Reasons, 0)
+	require.WithinDuration(t, time.Now().UTC().Add(time.Minute), results[0].ExpirationTime, 10*time.Second)
+
+	require.NoError(t, results[1].Err)
+	require.Equal(t, []cacheobject.Reason{cacheobject.ReasonResponseNoStore}, results[1].Reasons)
+
+	require.NoError(t, results[2].Err)
+	require.Equal(t, []cacheobject.Reason{cacheobject.ReasonRequestMethodPUT}, results[2].Reasons)
+
+	require.Error(t, results[3].Err)
+}
+
+func TestCachableBatchEmpty(t *testing.T) {
+	require.Len(t, CachableBatch(nil, Options{}), 0)
+}
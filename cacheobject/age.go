@@ -0,0 +1,110 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cacheobject
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// LOW LEVEL API: Calculates the age of a stored response, from the age it had when
+// it was received and the time it has resided in the cache since then:
+// http://tools.ietf.org/html/rfc7234#section-4.2.3
+//
+// When an Object for a stored response is evaluated again, setting RespAgeHeader to
+// the UpdatedAge keeps the expiration time calculated by ExpirationObject unchanged.
+// This is also the age a cache should forward to the next cache in a chain.
+func UpdatedAge(initialAge time.Duration, storedAt, now time.Time) time.Duration {
+	residentTime := now.Sub(storedAt)
+	if residentTime < 0 {
+		residentTime = 0
+	}
+	return initialAge + residentTime
+}
+
+// LOW LEVEL API: Returns the value of the Age header a cache should send with a stored
+// response, in whole seconds: http://tools.ietf.org/html/rfc7234#section-5.1
+//
+// It is the initialAge the response had when it was received plus the time it has resided
+// in the cache since storedAt, see UpdatedAge. Ages which don't fit in delta-seconds are
+// sent as 2147483648: http://tools.ietf.org/html/rfc7234#section-1.2.1
+func AgeHeaderValue(storedAt, now time.Time, initialAge time.Duration) string {
+	seconds := int64(UpdatedAge(initialAge, storedAt, now) / time.Second)
+	if seconds < 0 {
+		seconds = 0
+	} else if seconds > math.MaxInt32 {
+		seconds = math.MaxInt32 + 1
+	}
+	return strconv.FormatInt(seconds, 10)
+}
+
+// parses the Age header of a response, which is the age of the response when it
+// was forwarded by a previous cache: http://tools.ietf.org/html/rfc7234#section-5.1
+func parseAgeHeader(respHeaders http.Header) time.Duration {
+	v := respHeaders.Get("Age")
+	if v == "" {
+		return 0
+	}
+
+	age, err := parseDeltaSeconds(v)
+	if err != nil {
+		return 0
+	}
+	return time.Second * time.Duration(age)
+}
+
+// age of the response when it was received, accounting for the time the request and
+// response spent in transit: http://tools.ietf.org/html/rfc7234#section-4.2.3
+func correctedInitialAge(obj *Object) time.Duration {
+	var apparentAge time.Duration
+	if !obj.RespDateHeader.IsZero() {
+		apparentAge = obj.ResponseTime.Sub(obj.RespDateHeader)
+		if apparentAge < 0 {
+			apparentAge = 0
+		}
+	}
+
+	responseDelay := obj.ResponseTime.Sub(obj.RequestTime)
+	if responseDelay < 0 {
+		responseDelay = 0
+	}
+
+	correctedAgeValue := obj.RespAgeHeader + responseDelay
+	if apparentAge > correctedAgeValue {
+		return apparentAge
+	}
+	return correctedAgeValue
+}
+
+// age of the response at now, based on its Date and Age headers: http://tools.ietf.org/html/rfc7234#section-4.2.3
+func currentAge(obj *Object, now time.Time) time.Duration {
+	var apparentAge time.Duration
+	if !obj.RespDateHeader.IsZero() {
+		apparentAge = now.Sub(obj.RespDateHeader)
+		if apparentAge < 0 {
+			apparentAge = 0
+		}
+	}
+
+	if obj.RespAgeHeader > apparentAge {
+		return obj.RespAgeHeader
+	}
+	return apparentAge
+}
@@ -0,0 +1,229 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cacheobject
+
+import (
+	"github.com/stretchr/testify/require"
+
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestUpdatedAge(t *testing.T) {
+	now := time.Now().UTC()
+
+	require.Equal(t, time.Second*15, UpdatedAge(time.Second*5, now.Add(time.Second*-10), now))
+	// stored "in the future" does not reduce the age
+	require.Equal(t, time.Second*5, UpdatedAge(time.Second*5, now.Add(time.Second*10), now))
+}
+
+func TestUpdatedAgeCacheChain(t *testing.T) {
+	origin := time.Now().UTC()
+
+	// the first cache receives the response from the origin, and forwards it after 10s.
+	age := UpdatedAge(0, origin, origin.Add(time.Second*10))
+	require.Equal(t, time.Second*10, age)
+
+	// the second cache stores it at 12s, and evaluates it again at 17s.
+	age = UpdatedAge(age, origin.Add(time.Second*12), origin.Add(time.Second*17))
+	require.Equal(t, time.Second*15, age)
+}
+
+func TestParseAgeHeader(t *testing.T) {
+	h := http.Header{}
+	require.Equal(t, time.Duration(0), parseAgeHeader(h))
+
+	h.Set("Age", "30")
+	require.Equal(t, time.Second*30, parseAgeHeader(h))
+
+	h.Set("Age", "junk")
+	require.Equal(t, time.Duration(0), parseAgeHeader(h))
+}
+
+func TestExpirationAgeHeader(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespDirectives.MaxAge = DeltaSeconds(60)
+	obj.RespAgeHeader = time.Second * 20
+
+	rv := ObjectResults{}
+	ExpirationObject(&obj, &rv)
+	require.WithinDuration(t, now.Add(time.Second*40), rv.OutExpirationTime, time.Second*1)
+	expires := rv.OutExpirationTime
+
+	// evaluating the stored response again, 10s later, keeps the same expiration time.
+	later := now.Add(time.Second * 10)
+	obj.RespAgeHeader = UpdatedAge(obj.RespAgeHeader, now, later)
+	obj.NowUTC = later
+
+	ExpirationObject(&obj, &rv)
+	require.Equal(t, expires, rv.OutExpirationTime)
+}
+
+func TestCurrentAge(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespDateHeader = now.Add(time.Second * -10)
+	require.Equal(t, time.Second*10, currentAge(&obj, now))
+
+	obj.RespAgeHeader = time.Second * 30
+	require.Equal(t, time.Second*30, currentAge(&obj, now))
+}
+
+func TestExpirationAgeHeaderAlreadyStale(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespDirectives.MaxAge = DeltaSeconds(60)
+	obj.RespAgeHeader = time.Second * 600
+	obj.EmitInfoReasons = true
+
+	rv := ObjectResults{}
+	ExpirationObject(&obj, &rv)
+	require.Len(t, rv.OutReasons, 0)
+	require.Equal(t, []Reason{ReasonResponseAlreadyStaleOnReceipt}, rv.OutInfoReasons)
+	require.Equal(t, now.Add(time.Second*-540), rv.OutExpirationTime)
+	require.True(t, MustRevalidateBeforeUse(&obj, rv.OutExpirationTime))
+}
+
+func TestExpirationAgeHeaderAlreadyStaleNotEmittedByDefault(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespDirectives.MaxAge = DeltaSeconds(60)
+	obj.RespAgeHeader = time.Second * 600
+
+	rv := ObjectResults{}
+	ExpirationObject(&obj, &rv)
+	require.Len(t, rv.OutInfoReasons, 0)
+	require.Equal(t, now.Add(time.Second*-540), rv.OutExpirationTime)
+}
+
+func TestExpirationAgeHeaderNotStale(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespDirectives.MaxAge = DeltaSeconds(600)
+	obj.RespAgeHeader = time.Second * 60
+
+	rv := ObjectResults{}
+	ExpirationObject(&obj, &rv)
+	require.Len(t, rv.OutReasons, 0)
+}
+
+func TestExpirationAgeHeaderHeuristic(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespLastModifiedHeader = now.Add(time.Hour * -24 * 5)
+	obj.RespAgeHeader = time.Hour
+
+	rv := ObjectResults{}
+	ExpirationObject(&obj, &rv)
+	require.Len(t, rv.OutReasons, 0)
+	require.Equal(t, now.Add(time.Hour*11), rv.OutExpirationTime)
+}
+
+func TestExpirationAgeHeaderDefaultHeuristicTTL(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.DefaultHeuristicTTL = time.Hour
+	obj.RespAgeHeader = time.Minute * 10
+
+	rv := ObjectResults{}
+	ExpirationObject(&obj, &rv)
+	require.Len(t, rv.OutReasons, 0)
+	require.Equal(t, now.Add(time.Minute*50), rv.OutExpirationTime)
+}
+
+func TestExpirationResponseDelay(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespDirectives.MaxAge = DeltaSeconds(60)
+	obj.RespAgeHeader = time.Second * 10
+
+	rv := ObjectResults{}
+	ExpirationObject(&obj, &rv)
+	require.Equal(t, now.Add(time.Second*50), rv.OutExpirationTime)
+
+	// the request took 5s, so the response may have aged 5s more in transit.
+	obj.RequestTime = now.Add(time.Second * -5)
+	obj.ResponseTime = now
+
+	ExpirationObject(&obj, &rv)
+	require.Equal(t, now.Add(time.Second*45), rv.OutExpirationTime)
+}
+
+func TestExpirationResponseDelayApparentAge(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespDirectives.MaxAge = DeltaSeconds(60)
+	obj.RequestTime = now.Add(time.Second * -1)
+	obj.ResponseTime = now
+
+	// the origin's clock is behind ours, the Date header is older than the response delay.
+	obj.RespDateHeader = now.Add(time.Second * -20)
+
+	rv := ObjectResults{}
+	ExpirationObject(&obj, &rv)
+	require.Equal(t, now.Add(time.Second*40), rv.OutExpirationTime)
+}
+
+func TestExpirationResponseDelayResidentTime(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespDirectives.MaxAge = DeltaSeconds(60)
+	obj.RequestTime = now.Add(time.Second * -5)
+	obj.ResponseTime = now
+
+	rv := ObjectResults{}
+	ExpirationObject(&obj, &rv)
+	expires := rv.OutExpirationTime
+
+	// evaluating the stored response again, 10s later, keeps the same expiration time.
+	obj.NowUTC = now.Add(time.Second * 10)
+
+	ExpirationObject(&obj, &rv)
+	require.Equal(t, expires, rv.OutExpirationTime)
+}
+
+func TestAgeHeaderValue(t *testing.T) {
+	storedAt := time.Now().UTC()
+
+	require.Equal(t, "0", AgeHeaderValue(storedAt, storedAt, 0))
+	require.Equal(t, "15", AgeHeaderValue(storedAt, storedAt.Add(time.Second*10), time.Second*5))
+
+	// partial seconds are truncated
+	require.Equal(t, "14", AgeHeaderValue(storedAt, storedAt.Add(time.Millisecond*10900), time.Second*4))
+
+	// stored "in the future" does not reduce the age
+	require.Equal(t, "5", AgeHeaderValue(storedAt, storedAt.Add(time.Second*-10), time.Second*5))
+}
+
+func TestAgeHeaderValueOverflow(t *testing.T) {
+	storedAt := time.Now().UTC()
+
+	require.Equal(t, "2147483648", AgeHeaderValue(storedAt, storedAt.Add(time.Hour*24*365*100), 0))
+}
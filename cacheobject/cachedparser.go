@@ -0,0 +1,97 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cacheobject
+
+import (
+	"container/list"
+	"sync"
+)
+
+// LOW LEVEL API: Memoizes ParseResponseCacheControl for commonly repeated
+// `Cache-Control` headers, such as `public, max-age=3600`.
+//
+// The returned directives are shared between callers, and MUST NOT be modified.
+// A CachedParser is safe for concurrent use.
+type CachedParser struct {
+	maxEntries int
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+type cachedParserEntry struct {
+	value string
+	cd    *ResponseCacheDirectives
+}
+
+// Creates a CachedParser holding at most maxEntries parsed headers.
+func NewCachedParser(maxEntries int) *CachedParser {
+	if maxEntries < 1 {
+		maxEntries = 1
+	}
+
+	return &CachedParser{
+		maxEntries: maxEntries,
+		lru:        list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// Parses a Cache Control Header from a Response, or returns the previously parsed
+// directives for the same value. Values which fail to parse are not cached.
+func (cp *CachedParser) ParseResponseCacheControl(value string) (*ResponseCacheDirectives, error) {
+	cp.mu.Lock()
+	if e, ok := cp.entries[value]; ok {
+		cp.lru.MoveToFront(e)
+		cd := e.Value.(*cachedParserEntry).cd
+		cp.mu.Unlock()
+		return cd, nil
+	}
+	cp.mu.Unlock()
+
+	cd, err := ParseResponseCacheControl(value)
+	if err != nil {
+		return nil, err
+	}
+
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	if e, ok := cp.entries[value]; ok {
+		// parsed concurrently by another caller, share its result.
+		cp.lru.MoveToFront(e)
+		return e.Value.(*cachedParserEntry).cd, nil
+	}
+
+	cp.entries[value] = cp.lru.PushFront(&cachedParserEntry{value: value, cd: cd})
+	for cp.lru.Len() > cp.maxEntries {
+		oldest := cp.lru.Back()
+		cp.lru.Remove(oldest)
+		delete(cp.entries, oldest.Value.(*cachedParserEntry).value)
+	}
+
+	return cd, nil
+}
+
+// Returns the number of parsed headers currently held.
+func (cp *CachedParser) Len() int {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	return cp.lru.Len()
+}
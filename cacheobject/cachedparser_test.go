@@ -0,0 +1,97 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cacheobject
+
+import (
+	"github.com/stretchr/testify/require"
+
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestCachedParserHit(t *testing.T) {
+	cp := NewCachedParser(10)
+
+	cd, err := cp.ParseResponseCacheControl("public, max-age=3600")
+	require.NoError(t, err)
+	require.True(t, cd.Public)
+	require.Equal(t, cd.MaxAge, DeltaSeconds(3600))
+
+	cd2, err := cp.ParseResponseCacheControl("public, max-age=3600")
+	require.NoError(t, err)
+	require.True(t, cd == cd2, "expected the same directives to be returned")
+	require.Equal(t, 1, cp.Len())
+}
+
+func TestCachedParserError(t *testing.T) {
+	cp := NewCachedParser(10)
+
+	cd, err := cp.ParseResponseCacheControl("max-age")
+	require.Error(t, err)
+	require.Nil(t, cd)
+	require.Equal(t, 0, cp.Len())
+}
+
+func TestCachedParserEviction(t *testing.T) {
+	cp := NewCachedParser(2)
+
+	a, err := cp.ParseResponseCacheControl("max-age=1")
+	require.NoError(t, err)
+	_, err = cp.ParseResponseCacheControl("max-age=2")
+	require.NoError(t, err)
+
+	// touch max-age=1, so max-age=2 is the least recently used
+	a2, err := cp.ParseResponseCacheControl("max-age=1")
+	require.NoError(t, err)
+	require.True(t, a == a2)
+
+	_, err = cp.ParseResponseCacheControl("max-age=3")
+	require.NoError(t, err)
+	require.Equal(t, 2, cp.Len())
+
+	a3, err := cp.ParseResponseCacheControl("max-age=1")
+	require.NoError(t, err)
+	require.True(t, a == a3, "max-age=1 should not have been evicted")
+
+	cp.mu.Lock()
+	_, ok := cp.entries["max-age=2"]
+	cp.mu.Unlock()
+	require.False(t, ok, "max-age=2 should have been evicted")
+}
+
+func TestCachedParserConcurrent(t *testing.T) {
+	cp := NewCachedParser(8)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				v := fmt.Sprintf("max-age=%d", (i+j)%12)
+				cd, err := cp.ParseResponseCacheControl(v)
+				require.NoError(t, err)
+				require.Equal(t, cd.MaxAge, DeltaSeconds((i+j)%12))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	require.Equal(t, 8, cp.Len())
+}
@@ -22,6 +22,7 @@ import (
 	"math"
 	"net/http"
 	"net/textproto"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -41,6 +42,7 @@ var (
 	ErrMustRevalidateNoArgs  = errors.New("Unexpected argument to `must-revalidate`")
 	ErrPublicNoArgs          = errors.New("Unexpected argument to `public`")
 	ErrProxyRevalidateNoArgs = errors.New("Unexpected argument to `proxy-revalidate`")
+	ErrObsoleteLineFolding   = errors.New("Unexpected line folding in header value")
 	// Experimental
 	ErrImmutableNoArgs                  = errors.New("Unexpected argument to `immutable`")
 	ErrStaleIfErrorDeltaSeconds         = errors.New("Failed to parse delta-seconds in `stale-if-error`")
@@ -59,7 +61,8 @@ func parse(value string, cd cacheDirective) error {
 	i := 0
 
 	for i < len(value) && err == nil {
-		// eat leading whitespace or commas
+		// eat leading whitespace or commas, which skips empty directives, eg,
+		// `public, , max-age=60,` from carelessly joined headers.
 		if whitespace(value[i]) || value[i] == ',' {
 			i++
 			continue
@@ -128,6 +131,30 @@ func parse(value string, cd cacheDirective) error {
 	return err
 }
 
+// replaces obsolete line folding, a CRLF followed by whitespace, which may be left in a
+// header value that was joined manually, with a single space: http://tools.ietf.org/html/rfc7230#section-3.2.4
+//
+// Stray CRs and LFs are replaced too, so they aren't parsed as directives.
+func unfold(value string) string {
+	if !strings.ContainsAny(value, "\r\n") {
+		return value
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\r' && value[i] != '\n' {
+			b.WriteByte(value[i])
+			continue
+		}
+
+		for i+1 < len(value) && (value[i+1] == '\r' || value[i+1] == '\n' || whitespace(value[i+1])) {
+			i++
+		}
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
 // DeltaSeconds specifies a non-negative integer, representing
 // time in seconds: http://tools.ietf.org/html/rfc7234#section-1.2.1
 //
@@ -136,6 +163,12 @@ type DeltaSeconds int32
 
 // Parser for delta-seconds, a uint31, more or less:
 // http://tools.ietf.org/html/rfc7234#section-1.2.1
+//
+// delta-seconds is 1*DIGIT, so leading zeros are accepted (`0300` is 300),
+// but a sign, like `+5`, is rejected. Values too large for a DeltaSeconds are
+// clamped to its maximum: http://tools.ietf.org/html/rfc7234#section-1.2.1
+//
+// Every numeric directive, and the Age header, is parsed with this.
 func parseDeltaSeconds(v string) (DeltaSeconds, error) {
 	n, err := strconv.ParseUint(v, 10, 32)
 	if err != nil {
@@ -302,8 +335,30 @@ func (cd *RequestCacheDirectives) addPair(token string, v string) error {
 	return err
 }
 
+// Returns the names of the request directives understood by ParseRequestCacheControl.
+// Any other directive is recorded in RequestCacheDirectives.Extensions.
+func SupportedRequestDirectives() []string {
+	return []string{
+		"max-age",
+		"max-stale",
+		"min-fresh",
+		"no-cache",
+		"no-store",
+		"no-transform",
+		"only-if-cached",
+		"stale-if-error",
+	}
+}
+
 // LOW LEVEL API: Parses a Cache Control Header from a Request into a set of directives.
+//
+// Values containing obsolete line folding, or any CR or LF, are rejected with
+// ErrObsoleteLineFolding: http://tools.ietf.org/html/rfc7230#section-3.2.4
 func ParseRequestCacheControl(value string) (*RequestCacheDirectives, error) {
+	if strings.ContainsAny(value, "\r\n") {
+		return nil, ErrObsoleteLineFolding
+	}
+
 	cd := &RequestCacheDirectives{
 		MaxAge:   -1,
 		MaxStale: -1,
@@ -341,6 +396,8 @@ type ResponseCacheDirectives struct {
 	// fields in the response that have the field-name(s) listed MUST NOT be
 	// sent in the response to a subsequent request without successful
 	// revalidation with the origin server.
+	//
+	// Unlike the field-names of private, these apply to private and shared caches alike.
 	NoCache FieldNames
 
 	// no-cache(cast-to-bool): http://tools.ietf.org/html/rfc7234#section-5.2.2.2
@@ -357,6 +414,12 @@ type ResponseCacheDirectives struct {
 	// directive applies to both private and shared caches.
 	NoStore bool
 
+	// no-store(FieldName): non-standard, only parsed by ParseResponseCacheControlLenient
+	//
+	// Some upstreams send field-names with no-store, like the private directive.
+	// They are recorded here, and NoStore is set, so the response is still not stored.
+	NoStoreFields FieldNames
+
 	// no-transform(bool): http://tools.ietf.org/html/rfc7234#section-5.2.2.4
 	//
 	// The "no-transform" response directive indicates that an intermediary
@@ -436,10 +499,41 @@ type ResponseCacheDirectives struct {
 	// or more cache-extension tokens, each with an optional value.  A cache
 	// MUST ignore unrecognized cache directives.
 	Extensions []string
+
+	// Directives with invalid values, like `max-age=abc`, which were dropped by
+	// ParseResponseCacheControlLenient, rather than failing to parse the whole header.
+	InvalidDirectives []string
+}
+
+// Returns the names of the response directives understood by ParseResponseCacheControl.
+// Any other directive is recorded in ResponseCacheDirectives.Extensions.
+func SupportedResponseDirectives() []string {
+	return []string{
+		"must-revalidate",
+		"no-cache",
+		"no-store",
+		"no-transform",
+		"public",
+		"private",
+		"proxy-revalidate",
+		"max-age",
+		"s-maxage",
+		// Experimental
+		"immutable",
+		"stale-if-error",
+		"stale-while-revalidate",
+	}
 }
 
 // LOW LEVEL API: Parses a Cache Control Header from a Response into a set of directives.
+//
+// Values containing obsolete line folding, or any CR or LF, are rejected with
+// ErrObsoleteLineFolding: http://tools.ietf.org/html/rfc7230#section-3.2.4
 func ParseResponseCacheControl(value string) (*ResponseCacheDirectives, error) {
+	if strings.ContainsAny(value, "\r\n") {
+		return nil, ErrObsoleteLineFolding
+	}
+
 	cd := &ResponseCacheDirectives{
 		MaxAge:  -1,
 		SMaxAge: -1,
@@ -455,6 +549,89 @@ func ParseResponseCacheControl(value string) (*ResponseCacheDirectives, error) {
 	return cd, nil
 }
 
+// LOW LEVEL API: Parses a Cache Control Header from a Response into a set of directives,
+// tolerating common mistakes made by upstreams which ParseResponseCacheControl rejects:
+//
+//   - `no-store` with field-names, which is treated as a bare `no-store`, the field-names
+//     are only recorded in NoStoreFields. ParseResponseCacheControl rejects it with ErrNoStoreNoArgs.
+//   - values wrapped in single quotes, like `max-age='300'`
+//   - obsolete line folding, which is replaced with a single space
+//   - invalid delta-seconds, like `max-age=abc`, which are recorded in InvalidDirectives
+//     and otherwise ignored, so eg, an Expires header provides the freshness instead
+func ParseResponseCacheControlLenient(value string) (*ResponseCacheDirectives, error) {
+	cd, err := ParseResponseCacheControl("")
+	if err != nil {
+		return nil, err
+	}
+
+	err = parse(unfold(value), lenientResponseCacheDirectives{cd})
+	if err != nil {
+		return nil, err
+	}
+	return cd, nil
+}
+
+// Parses the Cache-Control headers of a Response into a set of directives. Multiple
+// Cache-Control headers are joined, as if they were sent as a single comma separated
+// list: http://tools.ietf.org/html/rfc7230#section-3.2.2
+func ResponseDirectives(resp *http.Response) (*ResponseCacheDirectives, error) {
+	return ParseResponseCacheControl(joinedHeader(resp.Header, "Cache-Control"))
+}
+
+// Parses the Cache-Control headers of a Request into a set of directives. Multiple
+// Cache-Control headers are joined, as if they were sent as a single comma separated
+// list: http://tools.ietf.org/html/rfc7230#section-3.2.2
+func RequestDirectives(req *http.Request) (*RequestCacheDirectives, error) {
+	return ParseRequestCacheControl(joinedHeader(req.Header, "Cache-Control"))
+}
+
+// Check if a request with these directives may not be stored, eg, in a request log, because
+// of Cache-Control: no-store: http://tools.ietf.org/html/rfc7234#section-5.2.1.5
+//
+// The response to the request may not be stored either, which is reported by
+// CachableObject as ReasonRequestNoStore.
+func MustNotStoreRequest(reqDir *RequestCacheDirectives) bool {
+	return reqDir != nil && reqDir.NoStore
+}
+
+func joinedHeader(headers http.Header, name string) string {
+	return strings.Join(headers.Values(name), ", ")
+}
+
+// lenient parsing of ResponseCacheDirectives, falls back to the strict parsing.
+type lenientResponseCacheDirectives struct {
+	*ResponseCacheDirectives
+}
+
+func (cd lenientResponseCacheDirectives) addPair(token string, v string) error {
+	if len(v) >= 2 && v[0] == '\'' && v[len(v)-1] == '\'' {
+		v = v[1 : len(v)-1]
+	}
+
+	switch token {
+	case "max-age", "s-maxage", "stale-if-error", "stale-while-revalidate":
+		if _, err := parseDeltaSeconds(v); err != nil {
+			cd.InvalidDirectives = append(cd.InvalidDirectives, token+"="+v)
+			return nil
+		}
+	case "no-store":
+		cd.NoStore = true
+		tokens := strings.Split(v, ",")
+		if cd.NoStoreFields == nil {
+			cd.NoStoreFields = make(FieldNames)
+		}
+		for _, t := range tokens {
+			k := http.CanonicalHeaderKey(textproto.TrimString(t))
+			if k != "" {
+				cd.NoStoreFields[k] = true
+			}
+		}
+		return nil
+	}
+
+	return cd.ResponseCacheDirectives.addPair(token, v)
+}
+
 func (cd *ResponseCacheDirectives) addToken(token string) error {
 	var err error = nil
 	switch token {
@@ -491,6 +668,9 @@ func hasFieldNames(token string) bool {
 		return true
 	case "private":
 		return true
+	case "no-store":
+		// only accepted by lenient parsing
+		return true
 	}
 	return false
 }
@@ -548,3 +728,364 @@ func (cd *ResponseCacheDirectives) addPair(token string, v string) error {
 
 	return err
 }
+
+// Quickly check if a response with these directives could ever be stored, before
+// calculating its freshness. Returns false for no-store, and for private when the
+// cache is shared: http://tools.ietf.org/html/rfc7234#section-3
+func (cd *ResponseCacheDirectives) IsCacheableAtAll(privateCache bool) bool {
+	if cd.NoStore {
+		return false
+	}
+
+	if cd.PrivatePresent && !privateCache {
+		return false
+	}
+
+	return true
+}
+
+// Check if two sets of directives have the same meaning. Unlike reflect.DeepEqual,
+// the order of Extensions is ignored, and nil and empty field-names are equal.
+func (cd *ResponseCacheDirectives) Equal(other *ResponseCacheDirectives) bool {
+	if cd == nil || other == nil {
+		return cd == other
+	}
+
+	return cd.MustRevalidate == other.MustRevalidate &&
+		cd.NoCachePresent == other.NoCachePresent &&
+		fieldNamesEqual(cd.NoCache, other.NoCache) &&
+		cd.NoStore == other.NoStore &&
+		fieldNamesEqual(cd.NoStoreFields, other.NoStoreFields) &&
+		cd.NoTransform == other.NoTransform &&
+		cd.Public == other.Public &&
+		cd.PrivatePresent == other.PrivatePresent &&
+		fieldNamesEqual(cd.Private, other.Private) &&
+		cd.ProxyRevalidate == other.ProxyRevalidate &&
+		cd.MaxAge == other.MaxAge &&
+		cd.SMaxAge == other.SMaxAge &&
+		cd.Immutable == other.Immutable &&
+		cd.StaleIfError == other.StaleIfError &&
+		cd.StaleWhileRevalidate == other.StaleWhileRevalidate &&
+		extensionsEqual(cd.Extensions, other.Extensions)
+}
+
+func fieldNamesEqual(a FieldNames, b FieldNames) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func extensionsEqual(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	counts := make(map[string]int, len(a))
+	for _, ext := range a {
+		counts[ext]++
+	}
+	for _, ext := range b {
+		counts[ext]--
+		if counts[ext] < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Merges two sets of response directives, eg, from Cache-Control and a targeted header like
+// CDN-Cache-Control, keeping the most restrictive value of each directive:
+//
+//   - must-revalidate, no-store, no-transform and proxy-revalidate apply if either sets them
+//   - no-cache and private apply to the union of their field-names, or to the whole
+//     response if either has no field-names
+//   - public and immutable only apply if both set them
+//   - max-age and s-maxage are the smaller of the values set
+//   - stale-if-error and stale-while-revalidate are the smaller value, if both set them
+//   - extensions are combined
+//
+// Neither a nor b are modified. If either is nil, a copy of the other is returned.
+func MergeResponseDirectives(a, b *ResponseCacheDirectives) *ResponseCacheDirectives {
+	if a == nil && b == nil {
+		return nil
+	}
+	if a == nil {
+		a, b = b, nil
+	}
+	if b == nil {
+		merged := *a
+		merged.NoCache = mergeFieldNames(a.NoCache, nil)
+		merged.NoStoreFields = mergeFieldNames(a.NoStoreFields, nil)
+		merged.Private = mergeFieldNames(a.Private, nil)
+		merged.Extensions = mergeExtensions(a.Extensions, nil)
+		return &merged
+	}
+
+	merged := &ResponseCacheDirectives{
+		MustRevalidate:  a.MustRevalidate || b.MustRevalidate,
+		NoCachePresent:  a.NoCachePresent || b.NoCachePresent,
+		NoStore:         a.NoStore || b.NoStore,
+		NoStoreFields:   mergeFieldNames(a.NoStoreFields, b.NoStoreFields),
+		NoTransform:     a.NoTransform || b.NoTransform,
+		Public:          a.Public && b.Public,
+		PrivatePresent:  a.PrivatePresent || b.PrivatePresent,
+		ProxyRevalidate: a.ProxyRevalidate || b.ProxyRevalidate,
+		MaxAge:          minDeltaSeconds(a.MaxAge, b.MaxAge),
+		SMaxAge:         minDeltaSeconds(a.SMaxAge, b.SMaxAge),
+		Immutable:       a.Immutable && b.Immutable,
+		Extensions:      mergeExtensions(a.Extensions, b.Extensions),
+
+		StaleIfError:         -1,
+		StaleWhileRevalidate: -1,
+	}
+
+	if a.StaleIfError != -1 && b.StaleIfError != -1 {
+		merged.StaleIfError = minDeltaSeconds(a.StaleIfError, b.StaleIfError)
+	}
+	if a.StaleWhileRevalidate != -1 && b.StaleWhileRevalidate != -1 {
+		merged.StaleWhileRevalidate = minDeltaSeconds(a.StaleWhileRevalidate, b.StaleWhileRevalidate)
+	}
+
+	// without field-names, the directive applies to the whole response
+	if !unqualified(a.NoCachePresent, a.NoCache) && !unqualified(b.NoCachePresent, b.NoCache) {
+		merged.NoCache = mergeFieldNames(a.NoCache, b.NoCache)
+	}
+	if !unqualified(a.PrivatePresent, a.Private) && !unqualified(b.PrivatePresent, b.Private) {
+		merged.Private = mergeFieldNames(a.Private, b.Private)
+	}
+
+	return merged
+}
+
+func unqualified(present bool, fields FieldNames) bool {
+	return present && len(fields) == 0
+}
+
+func minDeltaSeconds(a DeltaSeconds, b DeltaSeconds) DeltaSeconds {
+	if a == -1 || (b != -1 && b < a) {
+		return b
+	}
+	return a
+}
+
+func mergeFieldNames(a FieldNames, b FieldNames) FieldNames {
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+
+	merged := make(FieldNames, len(a)+len(b))
+	for k := range a {
+		merged[k] = true
+	}
+	for k := range b {
+		merged[k] = true
+	}
+	return merged
+}
+
+func mergeExtensions(a []string, b []string) []string {
+	var merged []string
+	seen := map[string]bool{}
+	for _, ext := range append(append([]string{}, a...), b...) {
+		if !seen[ext] {
+			seen[ext] = true
+			merged = append(merged, ext)
+		}
+	}
+	return merged
+}
+
+// names of the directives which extend RFC 7234, whether they are parsed, like
+// stale-while-revalidate, or not: http://tools.ietf.org/html/rfc7234#section-5.2.3
+func (cd *ResponseCacheDirectives) extensionNames() []string {
+	var names []string
+	if cd.Immutable {
+		names = append(names, "immutable")
+	}
+	if cd.StaleIfError != -1 {
+		names = append(names, "stale-if-error")
+	}
+	if cd.StaleWhileRevalidate != -1 {
+		names = append(names, "stale-while-revalidate")
+	}
+	for _, ext := range cd.Extensions {
+		if i := strings.IndexByte(ext, '='); i != -1 {
+			ext = ext[:i]
+		}
+		names = append(names, ext)
+	}
+	return names
+}
+
+// Returns the extension directives which look like a misspelled supported directive, eg,
+// `maxage` for `max-age`, mapped to the directive that was likely intended. Extensions
+// which don't resemble a supported directive are not included.
+func (cd *ResponseCacheDirectives) SuspiciousExtensions() map[string]string {
+	suspicious := map[string]string{}
+	for _, ext := range cd.Extensions {
+		token := ext
+		if i := strings.IndexByte(ext, '='); i != -1 {
+			token = ext[:i]
+		}
+
+		best := ""
+		bestDistance := 0
+		for _, directive := range SupportedResponseDirectives() {
+			distance := levenshtein(token, directive)
+			if distance > 2 || distance*3 > len(directive) {
+				continue
+			}
+			if best == "" || distance < bestDistance {
+				best = directive
+				bestDistance = distance
+			}
+		}
+
+		if best != "" {
+			suspicious[token] = best
+		}
+	}
+	return suspicious
+}
+
+// the edit distance between two strings
+func levenshtein(a string, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// Serializes the directives into a `Cache-Control` header value, in a stable order.
+func (cd *ResponseCacheDirectives) String() string {
+	return strings.Join(cd.directives(), ", ")
+}
+
+// LOW LEVEL API: Normalizes a response `Cache-Control` header value, so equivalent values,
+// like `max-age=60,public` and `public, max-age=60`, are identical, eg, to deduplicate them.
+//
+// The value is parsed with ParseResponseCacheControl, and its directives are serialized
+// like String(), but sorted, with duplicates removed.
+func Canonicalize(value string) (string, error) {
+	cd, err := ParseResponseCacheControl(value)
+	if err != nil {
+		return "", err
+	}
+
+	parts := cd.directives()
+	sort.Strings(parts)
+
+	unique := parts[:0]
+	for i, part := range parts {
+		if i == 0 || part != parts[i-1] {
+			unique = append(unique, part)
+		}
+	}
+	return strings.Join(unique, ", "), nil
+}
+
+func (cd *ResponseCacheDirectives) directives() []string {
+	var parts []string
+
+	if cd.MustRevalidate {
+		parts = append(parts, "must-revalidate")
+	}
+	if cd.NoCachePresent {
+		parts = append(parts, fieldNamesDirective("no-cache", cd.NoCache))
+	}
+	if cd.NoStore {
+		parts = append(parts, fieldNamesDirective("no-store", cd.NoStoreFields))
+	}
+	if cd.NoTransform {
+		parts = append(parts, "no-transform")
+	}
+	if cd.Public {
+		parts = append(parts, "public")
+	}
+	if cd.PrivatePresent {
+		parts = append(parts, fieldNamesDirective("private", cd.Private))
+	}
+	if cd.ProxyRevalidate {
+		parts = append(parts, "proxy-revalidate")
+	}
+	if cd.MaxAge != -1 {
+		parts = append(parts, "max-age="+strconv.Itoa(int(cd.MaxAge)))
+	}
+	if cd.SMaxAge != -1 {
+		parts = append(parts, "s-maxage="+strconv.Itoa(int(cd.SMaxAge)))
+	}
+	if cd.Immutable {
+		parts = append(parts, "immutable")
+	}
+	if cd.StaleIfError != -1 {
+		parts = append(parts, "stale-if-error="+strconv.Itoa(int(cd.StaleIfError)))
+	}
+	if cd.StaleWhileRevalidate != -1 {
+		parts = append(parts, "stale-while-revalidate="+strconv.Itoa(int(cd.StaleWhileRevalidate)))
+	}
+	for _, ext := range cd.Extensions {
+		parts = append(parts, extensionDirective(ext))
+	}
+
+	return parts
+}
+
+func fieldNamesDirective(token string, fields FieldNames) string {
+	if len(fields) == 0 {
+		return token
+	}
+
+	names := make([]string, 0, len(fields))
+	for k := range fields {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	return token + `="` + strings.Join(names, ", ") + `"`
+}
+
+// Extensions are stored as `token` or `token=value`, the value is quoted if needed.
+func extensionDirective(ext string) string {
+	i := strings.IndexByte(ext, '=')
+	if i == -1 {
+		return ext
+	}
+
+	v := ext[i+1:]
+	for j := 0; j < len(v); j++ {
+		if !isToken(v[j]) {
+			return ext[:i+1] + strconv.Quote(v)
+		}
+	}
+	if v == "" {
+		return ext[:i+1] + `""`
+	}
+	return ext
+}
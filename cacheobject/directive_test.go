@@ -23,7 +23,10 @@ import (
 
 	"fmt"
 	"math"
+	"net/http"
+	"strconv"
 	"testing"
+	"testing/quick"
 )
 
 func TestMaxAge(t *testing.T) {
@@ -267,7 +270,7 @@ func TestResStaleIfErrorBare(t *testing.T) {
 	require.NotNil(t, cd)
 
 	// `stale-if-error` without value is treated like an extension directive
-	require.Equal(t, cd.StaleIfError, -1)
+	require.Equal(t, cd.StaleIfError, DeltaSeconds(-1))
 	assert.Contains(t, cd.Extensions, "stale-if-error")
 }
 
@@ -312,6 +315,78 @@ func TestParseDeltaSecondsNegative(t *testing.T) {
 	require.Equal(t, DeltaSeconds(-1), ds)
 }
 
+func TestParseDeltaSecondsOverflow(t *testing.T) {
+	ds, err := parseDeltaSeconds("99999999999999999999999999999999")
+	require.NoError(t, err)
+	require.Equal(t, DeltaSeconds(math.MaxInt32), ds)
+
+	ds, err = parseDeltaSeconds(fmt.Sprintf("%d", int64(math.MaxInt32)))
+	require.NoError(t, err)
+	require.Equal(t, DeltaSeconds(math.MaxInt32), ds)
+}
+
+func TestParseDeltaSecondsInvalid(t *testing.T) {
+	for _, v := range []string{"", " ", "+5", " 5", "5 ", "1.5", "1e3", "0x10", "５", "1_000"} {
+		ds, err := parseDeltaSeconds(v)
+		require.Error(t, err, "%q", v)
+		require.Equal(t, DeltaSeconds(-1), ds, "%q", v)
+	}
+}
+
+// go 1.16 has no native fuzzing, so random inputs are checked with testing/quick instead.
+func TestParseDeltaSecondsQuick(t *testing.T) {
+	valid := func(v string) bool {
+		ds, err := parseDeltaSeconds(v)
+		if err != nil {
+			return ds == -1
+		}
+		return ds >= 0 && ds <= math.MaxInt32
+	}
+	require.NoError(t, quick.Check(valid, nil))
+
+	digits := func(n uint64) bool {
+		ds, err := parseDeltaSeconds(strconv.FormatUint(n, 10))
+		if n > math.MaxInt32 {
+			return err == nil && ds == math.MaxInt32
+		}
+		return err == nil && ds == DeltaSeconds(n)
+	}
+	require.NoError(t, quick.Check(digits, nil))
+}
+
+func TestParseDeltaSecondsDirectives(t *testing.T) {
+	// every numeric directive clamps large values and rejects invalid ones alike
+	resDirectives := map[string]func(cd *ResponseCacheDirectives) DeltaSeconds{
+		"max-age":                func(cd *ResponseCacheDirectives) DeltaSeconds { return cd.MaxAge },
+		"s-maxage":               func(cd *ResponseCacheDirectives) DeltaSeconds { return cd.SMaxAge },
+		"stale-if-error":         func(cd *ResponseCacheDirectives) DeltaSeconds { return cd.StaleIfError },
+		"stale-while-revalidate": func(cd *ResponseCacheDirectives) DeltaSeconds { return cd.StaleWhileRevalidate },
+	}
+	for name, field := range resDirectives {
+		cd, err := ParseResponseCacheControl(name + "=99999999999999999999")
+		require.NoError(t, err, name)
+		require.Equal(t, DeltaSeconds(math.MaxInt32), field(cd), name)
+
+		_, err = ParseResponseCacheControl(name + "=-1")
+		require.Error(t, err, name)
+	}
+
+	reqDirectives := map[string]func(cd *RequestCacheDirectives) DeltaSeconds{
+		"max-age":        func(cd *RequestCacheDirectives) DeltaSeconds { return cd.MaxAge },
+		"max-stale":      func(cd *RequestCacheDirectives) DeltaSeconds { return cd.MaxStale },
+		"min-fresh":      func(cd *RequestCacheDirectives) DeltaSeconds { return cd.MinFresh },
+		"stale-if-error": func(cd *RequestCacheDirectives) DeltaSeconds { return cd.StaleIfError },
+	}
+	for name, field := range reqDirectives {
+		cd, err := ParseRequestCacheControl(name + "=99999999999999999999")
+		require.NoError(t, err, name)
+		require.Equal(t, DeltaSeconds(math.MaxInt32), field(cd), name)
+
+		_, err = ParseRequestCacheControl(name + "=-1")
+		require.Error(t, err, name)
+	}
+}
+
 func TestReqNoCacheNoArgs(t *testing.T) {
 	cd, err := ParseRequestCacheControl(`no-cache=234`)
 	require.Error(t, err)
@@ -481,3 +556,481 @@ func TestNoSpacesIssue3PrivateFields(t *testing.T) {
 	require.Equal(t, true, cd.Private["Set-Cookie"])
 	require.Equal(t, true, cd.Private["Hello"])
 }
+
+func TestSupportedRequestDirectives(t *testing.T) {
+	supported := SupportedRequestDirectives()
+	require.Contains(t, supported, "max-age")
+	require.Contains(t, supported, "no-store")
+	require.Contains(t, supported, "only-if-cached")
+	require.NotContains(t, supported, "public")
+
+	for _, name := range supported {
+		cd, err := ParseRequestCacheControl(name + "=1")
+		if err == nil {
+			require.NotContains(t, cd.Extensions, name+"=1")
+		}
+	}
+}
+
+func TestSupportedResponseDirectives(t *testing.T) {
+	supported := SupportedResponseDirectives()
+	require.Contains(t, supported, "max-age")
+	require.Contains(t, supported, "no-store")
+	require.Contains(t, supported, "s-maxage")
+	require.NotContains(t, supported, "only-if-cached")
+
+	for _, name := range supported {
+		cd, err := ParseResponseCacheControl(name + "=1")
+		if err == nil {
+			require.NotContains(t, cd.Extensions, name+"=1")
+		}
+	}
+}
+
+func TestResNoStoreFieldsStrict(t *testing.T) {
+	cd, err := ParseResponseCacheControl(`no-store="Set-Cookie"`)
+	require.Error(t, err)
+	require.Nil(t, cd)
+	require.Equal(t, err, ErrNoStoreNoArgs)
+}
+
+func TestResNoStoreFieldsLenient(t *testing.T) {
+	cd, err := ParseResponseCacheControlLenient(`no-store="Set-Cookie,request-id", max-age=60`)
+	require.NoError(t, err)
+	require.True(t, cd.NoStore)
+	require.Equal(t, len(cd.NoStoreFields), 2)
+	require.True(t, cd.NoStoreFields["Set-Cookie"])
+	require.True(t, cd.NoStoreFields["Request-Id"])
+	require.Equal(t, cd.MaxAge, DeltaSeconds(60))
+	require.Equal(t, len(cd.Extensions), 0)
+
+	cd, err = ParseResponseCacheControlLenient(`no-store=Set-Cookie,Request-Id public`)
+	require.NoError(t, err)
+	require.True(t, cd.NoStore)
+	require.True(t, cd.Public)
+	require.Equal(t, len(cd.NoStoreFields), 2)
+}
+
+func TestResLenientStillStrictElsewhere(t *testing.T) {
+	cd, err := ParseResponseCacheControlLenient(`no-store`)
+	require.NoError(t, err)
+	require.True(t, cd.NoStore)
+	require.Nil(t, cd.NoStoreFields)
+
+	_, err = ParseResponseCacheControlLenient(`public=1`)
+	require.Equal(t, err, ErrPublicNoArgs)
+}
+
+func TestResString(t *testing.T) {
+	cd, err := ParseResponseCacheControl(`max-age=60, public, no-cache="Set-Cookie,Request-Id", foo=bar, baz="a b", immutable`)
+	require.NoError(t, err)
+	require.Equal(t, `no-cache="Request-Id, Set-Cookie", public, max-age=60, immutable, foo=bar, baz="a b"`, cd.String())
+
+	cd2, err := ParseResponseCacheControl(cd.String())
+	require.NoError(t, err)
+	require.Equal(t, cd, cd2)
+}
+
+func TestResStringEmpty(t *testing.T) {
+	cd, err := ParseResponseCacheControl("")
+	require.NoError(t, err)
+	require.Equal(t, "", cd.String())
+}
+
+func TestResMaxAgeLeadingZeros(t *testing.T) {
+	cd, err := ParseResponseCacheControl("max-age=0300")
+	require.NoError(t, err)
+	require.Equal(t, cd.MaxAge, DeltaSeconds(300))
+}
+
+func TestResMaxAgePlusSign(t *testing.T) {
+	cd, err := ParseResponseCacheControl("max-age=+5")
+	require.Error(t, err)
+	require.Nil(t, cd)
+
+	_, err = ParseRequestCacheControl("max-age=+5")
+	require.Equal(t, err, ErrMaxAgeDeltaSeconds)
+}
+
+func TestResMaxAgeTrailingSpace(t *testing.T) {
+	cd, err := ParseResponseCacheControl("max-age=300 ")
+	require.NoError(t, err)
+	require.Equal(t, cd.MaxAge, DeltaSeconds(300))
+}
+
+func TestResEqualExtensionOrder(t *testing.T) {
+	a, err := ParseResponseCacheControl(`public, foo, bar=1, max-age=60`)
+	require.NoError(t, err)
+	b, err := ParseResponseCacheControl(`max-age=60, bar=1, foo, public`)
+	require.NoError(t, err)
+
+	require.NotEqual(t, a.Extensions, b.Extensions)
+	require.True(t, a.Equal(b))
+	require.True(t, b.Equal(a))
+}
+
+func TestResEqualRoundTrip(t *testing.T) {
+	a, err := ParseResponseCacheControl(`private="Set-Cookie,Request-Id", no-cache, s-maxage=30, foo="a b"`)
+	require.NoError(t, err)
+	b, err := ParseResponseCacheControl(a.String())
+	require.NoError(t, err)
+
+	require.True(t, a.Equal(b))
+}
+
+func TestResNotEqual(t *testing.T) {
+	a, err := ParseResponseCacheControl(`public, max-age=60`)
+	require.NoError(t, err)
+
+	for _, v := range []string{
+		`public, max-age=61`,
+		`max-age=60`,
+		`public, max-age=60, foo`,
+		`public, max-age=60, private=Set-Cookie`,
+	} {
+		b, err := ParseResponseCacheControl(v)
+		require.NoError(t, err)
+		require.False(t, a.Equal(b), "directives should not be equal: %s", v)
+	}
+
+	require.False(t, a.Equal(nil))
+}
+
+func TestResEqualFieldNames(t *testing.T) {
+	a, err := ParseResponseCacheControl(`private="Set-Cookie"`)
+	require.NoError(t, err)
+	b, err := ParseResponseCacheControl(`private="X-Other"`)
+	require.NoError(t, err)
+	require.False(t, a.Equal(b))
+
+	c, err := ParseResponseCacheControl(`private`)
+	require.NoError(t, err)
+	c.Private = FieldNames{}
+	d, err := ParseResponseCacheControl(`private`)
+	require.NoError(t, err)
+	require.True(t, c.Equal(d))
+}
+
+func TestResponseDirectives(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Add("Cache-Control", "public")
+	resp.Header.Add("Cache-Control", "max-age=60, s-maxage=120")
+
+	cd, err := ResponseDirectives(resp)
+	require.NoError(t, err)
+	require.True(t, cd.Public)
+	require.Equal(t, DeltaSeconds(60), cd.MaxAge)
+	require.Equal(t, DeltaSeconds(120), cd.SMaxAge)
+}
+
+func TestResponseDirectivesNone(t *testing.T) {
+	cd, err := ResponseDirectives(&http.Response{Header: http.Header{}})
+	require.NoError(t, err)
+	require.Equal(t, DeltaSeconds(-1), cd.MaxAge)
+}
+
+func TestResponseDirectivesInvalid(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Add("Cache-Control", "public")
+	resp.Header.Add("Cache-Control", "max-age=foo")
+
+	_, err := ResponseDirectives(resp)
+	require.Error(t, err)
+}
+
+func TestRequestDirectives(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+	req.Header.Add("Cache-Control", "no-cache")
+	req.Header.Add("Cache-Control", "max-stale=30")
+
+	cd, err := RequestDirectives(req)
+	require.NoError(t, err)
+	require.True(t, cd.NoCache)
+	require.Equal(t, DeltaSeconds(30), cd.MaxStale)
+}
+
+func TestResSingleQuotedLenient(t *testing.T) {
+	cd, err := ParseResponseCacheControlLenient(`max-age='300', s-maxage='600'`)
+	require.NoError(t, err)
+	require.Equal(t, DeltaSeconds(300), cd.MaxAge)
+	require.Equal(t, DeltaSeconds(600), cd.SMaxAge)
+
+	_, err = ParseResponseCacheControl(`max-age='300'`)
+	require.Error(t, err)
+}
+
+func TestMustNotStoreRequest(t *testing.T) {
+	cd, err := ParseRequestCacheControl("no-store")
+	require.NoError(t, err)
+	require.True(t, MustNotStoreRequest(cd))
+
+	cd, err = ParseRequestCacheControl("no-cache, max-age=0")
+	require.NoError(t, err)
+	require.False(t, MustNotStoreRequest(cd))
+
+	require.False(t, MustNotStoreRequest(nil))
+}
+
+func TestSuspiciousExtensions(t *testing.T) {
+	cd, err := ParseResponseCacheControl("maxage=60, no-cahe, Pubilc, community=UCI")
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]string{
+		"maxage":  "max-age",
+		"no-cahe": "no-cache",
+		"pubilc":  "public",
+	}, cd.SuspiciousExtensions())
+}
+
+func TestSuspiciousExtensionsNone(t *testing.T) {
+	cd, err := ParseResponseCacheControl("max-age=60, community=UCI")
+	require.NoError(t, err)
+	require.Len(t, cd.SuspiciousExtensions(), 0)
+
+	cd, err = ParseResponseCacheControl("")
+	require.NoError(t, err)
+	require.Len(t, cd.SuspiciousExtensions(), 0)
+}
+
+func TestLevenshtein(t *testing.T) {
+	require.Equal(t, 0, levenshtein("max-age", "max-age"))
+	require.Equal(t, 1, levenshtein("maxage", "max-age"))
+	require.Equal(t, 2, levenshtein("pubilc", "public"))
+	require.Equal(t, 3, levenshtein("", "abc"))
+	require.Equal(t, 3, levenshtein("kitten", "sitting"))
+}
+
+func TestMergeResponseDirectivesMaxAge(t *testing.T) {
+	a, err := ParseResponseCacheControl("public, max-age=300, s-maxage=600, stale-while-revalidate=30")
+	require.NoError(t, err)
+	b, err := ParseResponseCacheControl("public, max-age=60")
+	require.NoError(t, err)
+
+	merged := MergeResponseDirectives(a, b)
+	require.Equal(t, DeltaSeconds(60), merged.MaxAge)
+	require.Equal(t, DeltaSeconds(600), merged.SMaxAge)
+	require.Equal(t, DeltaSeconds(-1), merged.StaleWhileRevalidate)
+	require.True(t, merged.Public)
+	require.Equal(t, "public, max-age=60, s-maxage=600", merged.String())
+
+	// the inputs are not modified
+	require.Equal(t, DeltaSeconds(300), a.MaxAge)
+}
+
+func TestMergeResponseDirectivesNoStore(t *testing.T) {
+	a, err := ParseResponseCacheControl("public, max-age=300, immutable")
+	require.NoError(t, err)
+	b, err := ParseResponseCacheControl("no-store")
+	require.NoError(t, err)
+
+	merged := MergeResponseDirectives(a, b)
+	require.True(t, merged.NoStore)
+	require.False(t, merged.Public)
+	require.False(t, merged.Immutable)
+	require.Equal(t, DeltaSeconds(300), merged.MaxAge)
+
+	require.True(t, MergeResponseDirectives(b, a).Equal(merged))
+}
+
+func TestMergeResponseDirectivesFieldNames(t *testing.T) {
+	a, err := ParseResponseCacheControl(`private="Set-Cookie", no-cache="X-Request-Id", community="UCI"`)
+	require.NoError(t, err)
+	b, err := ParseResponseCacheControl(`private="Authorization-Info", no-cache, community="UCI"`)
+	require.NoError(t, err)
+
+	merged := MergeResponseDirectives(a, b)
+	require.True(t, merged.PrivatePresent)
+	require.Equal(t, FieldNames{"Set-Cookie": true, "Authorization-Info": true}, merged.Private)
+	require.True(t, merged.NoCachePresent)
+	require.Len(t, merged.NoCache, 0)
+	require.Equal(t, []string{"community=UCI"}, merged.Extensions)
+}
+
+func TestMergeResponseDirectivesNil(t *testing.T) {
+	a, err := ParseResponseCacheControl(`max-age=60, no-cache="Set-Cookie"`)
+	require.NoError(t, err)
+
+	merged := MergeResponseDirectives(nil, a)
+	require.True(t, merged.Equal(a))
+
+	merged.NoCache["X-Request-Id"] = true
+	require.Len(t, a.NoCache, 1)
+
+	require.Nil(t, MergeResponseDirectives(nil, nil))
+}
+
+func TestResEmptyDirectivesLeadingComma(t *testing.T) {
+	cd, err := ParseResponseCacheControl(`, public, max-age=60`)
+	require.NoError(t, err)
+	require.True(t, cd.Public)
+	require.Equal(t, DeltaSeconds(60), cd.MaxAge)
+	require.Len(t, cd.Extensions, 0)
+}
+
+func TestResEmptyDirectivesTrailingComma(t *testing.T) {
+	cd, err := ParseResponseCacheControl(`public, max-age=60,`)
+	require.NoError(t, err)
+	require.True(t, cd.Public)
+	require.Equal(t, DeltaSeconds(60), cd.MaxAge)
+	require.Len(t, cd.Extensions, 0)
+}
+
+func TestResEmptyDirectivesDoubledComma(t *testing.T) {
+	cd, err := ParseResponseCacheControl(`public, , max-age=60,,no-transform`)
+	require.NoError(t, err)
+	require.True(t, cd.Public)
+	require.True(t, cd.NoTransform)
+	require.Equal(t, DeltaSeconds(60), cd.MaxAge)
+	require.Len(t, cd.Extensions, 0)
+}
+
+func TestReqEmptyDirectives(t *testing.T) {
+	cd, err := ParseRequestCacheControl(`, no-cache, , max-stale=30,`)
+	require.NoError(t, err)
+	require.True(t, cd.NoCache)
+	require.Equal(t, DeltaSeconds(30), cd.MaxStale)
+	require.Len(t, cd.Extensions, 0)
+}
+
+func TestResObsoleteLineFolding(t *testing.T) {
+	_, err := ParseResponseCacheControl("public,\r\n max-age=60")
+	require.Equal(t, ErrObsoleteLineFolding, err)
+
+	_, err = ParseResponseCacheControl("public,\nmax-age=60")
+	require.Equal(t, ErrObsoleteLineFolding, err)
+}
+
+func TestResLenientObsoleteLineFolding(t *testing.T) {
+	cd, err := ParseResponseCacheControlLenient("public,\r\n max-age=60")
+	require.NoError(t, err)
+	require.True(t, cd.Public)
+	require.Equal(t, DeltaSeconds(60), cd.MaxAge)
+	require.Len(t, cd.Extensions, 0)
+}
+
+func TestResLenientObsoleteLineFoldingQuoted(t *testing.T) {
+	cd, err := ParseResponseCacheControlLenient("private=\"Set-Cookie,\r\n\tX-Session\", max-age=60")
+	require.NoError(t, err)
+	require.Equal(t, DeltaSeconds(60), cd.MaxAge)
+	require.Len(t, cd.Private, 2)
+	require.True(t, cd.Private["Set-Cookie"])
+	require.True(t, cd.Private["X-Session"])
+	require.Len(t, cd.Extensions, 0)
+}
+
+func TestReqObsoleteLineFolding(t *testing.T) {
+	_, err := ParseRequestCacheControl("no-cache,\r\n max-stale=30")
+	require.Equal(t, ErrObsoleteLineFolding, err)
+
+	_, err = ParseRequestCacheControl("no-cache\nmax-stale=30")
+	require.Equal(t, ErrObsoleteLineFolding, err)
+}
+
+func TestResIsCacheableAtAll(t *testing.T) {
+	cd, err := ParseResponseCacheControl("public, max-age=60")
+	require.NoError(t, err)
+	require.True(t, cd.IsCacheableAtAll(false))
+	require.True(t, cd.IsCacheableAtAll(true))
+}
+
+func TestResIsCacheableAtAllNoStore(t *testing.T) {
+	cd, err := ParseResponseCacheControl("no-store, max-age=60")
+	require.NoError(t, err)
+	require.False(t, cd.IsCacheableAtAll(false))
+	require.False(t, cd.IsCacheableAtAll(true))
+}
+
+func TestResIsCacheableAtAllPrivate(t *testing.T) {
+	cd, err := ParseResponseCacheControl("private, max-age=60")
+	require.NoError(t, err)
+	require.False(t, cd.IsCacheableAtAll(false))
+	require.True(t, cd.IsCacheableAtAll(true))
+
+	cd, err = ParseResponseCacheControl(`private="Set-Cookie"`)
+	require.NoError(t, err)
+	require.False(t, cd.IsCacheableAtAll(false))
+}
+
+func TestResMustRevalidateProxyRevalidateIndependent(t *testing.T) {
+	cd, err := ParseResponseCacheControl(`max-age=60, must-revalidate`)
+	require.NoError(t, err)
+	require.True(t, cd.MustRevalidate)
+	require.False(t, cd.ProxyRevalidate)
+
+	cd, err = ParseResponseCacheControl(`max-age=60, proxy-revalidate`)
+	require.NoError(t, err)
+	require.False(t, cd.MustRevalidate)
+	require.True(t, cd.ProxyRevalidate)
+
+	cd, err = ParseResponseCacheControl(`Must-Revalidate, PROXY-REVALIDATE`)
+	require.NoError(t, err)
+	require.True(t, cd.MustRevalidate)
+	require.True(t, cd.ProxyRevalidate)
+}
+
+func TestResNoStoreArgument(t *testing.T) {
+	cd, err := ParseResponseCacheControl(`no-store="x"`)
+	require.Equal(t, ErrNoStoreNoArgs, err)
+	require.Nil(t, cd)
+}
+
+func TestResNoStoreArgumentLenient(t *testing.T) {
+	cd, err := ParseResponseCacheControlLenient(`no-store="x"`)
+	require.NoError(t, err)
+	require.True(t, cd.NoStore)
+	require.True(t, cd.NoStoreFields["X"])
+	require.Len(t, cd.Extensions, 0)
+	require.False(t, cd.IsCacheableAtAll(true))
+}
+
+func TestCanonicalize(t *testing.T) {
+	a, err := Canonicalize(`max-age=60,public`)
+	require.NoError(t, err)
+	require.Equal(t, "max-age=60, public", a)
+
+	b, err := Canonicalize(`public, max-age=60`)
+	require.NoError(t, err)
+	require.Equal(t, a, b)
+
+	c, err := Canonicalize(`Public,  MAX-AGE=60, public`)
+	require.NoError(t, err)
+	require.Equal(t, a, c)
+}
+
+func TestCanonicalizeFieldNamesAndExtensions(t *testing.T) {
+	a, err := Canonicalize(`no-cache="X-Foo, Set-Cookie", community="UCI", max-age=0`)
+	require.NoError(t, err)
+
+	b, err := Canonicalize(`max-age=0, community=UCI, no-cache="set-cookie,x-foo"`)
+	require.NoError(t, err)
+	require.Equal(t, a, b)
+	require.Equal(t, `community=UCI, max-age=0, no-cache="Set-Cookie, X-Foo"`, a)
+}
+
+func TestCanonicalizeInvalid(t *testing.T) {
+	_, err := Canonicalize(`max-age=60, no-cache="Set-Cookie`)
+	require.Equal(t, ErrQuoteMismatch, err)
+
+	_, err = Canonicalize(`public=1`)
+	require.Equal(t, ErrPublicNoArgs, err)
+}
+
+func TestCanonicalizeEmpty(t *testing.T) {
+	v, err := Canonicalize("")
+	require.NoError(t, err)
+	require.Equal(t, "", v)
+}
+
+func TestResInvalidMaxAgeLenient(t *testing.T) {
+	_, err := ParseResponseCacheControl(`max-age=abc, public`)
+	require.Error(t, err)
+
+	cd, err := ParseResponseCacheControlLenient(`max-age=abc, public, s-maxage=60`)
+	require.NoError(t, err)
+	require.Equal(t, DeltaSeconds(-1), cd.MaxAge)
+	require.Equal(t, DeltaSeconds(60), cd.SMaxAge)
+	require.True(t, cd.Public)
+	require.Equal(t, []string{"max-age=abc"}, cd.InvalidDirectives)
+	require.Len(t, cd.Extensions, 0)
+}
@@ -0,0 +1,43 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cacheobject
+
+import (
+	"net/http"
+	"time"
+)
+
+// LOW LEVEL API: Calculate an `Expires` header value equivalent to the freshness directives
+// of a response, for HTTP/1.0 caches which don't understand `Cache-Control`:
+// http://tools.ietf.org/html/rfc7234#section-5.3
+//
+// The value is based on max-age, or s-maxage without max-age, starting at now. Returns false
+// if neither directive is present.
+func ExpiresHeaderValue(respDir *ResponseCacheDirectives, now time.Time) (string, bool) {
+	lifetime := respDir.MaxAge
+	if lifetime == -1 {
+		lifetime = respDir.SMaxAge
+	}
+
+	if lifetime == -1 {
+		return "", false
+	}
+
+	expires := now.Add(time.Second * time.Duration(lifetime))
+	return expires.UTC().Format(http.TimeFormat), true
+}
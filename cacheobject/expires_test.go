@@ -0,0 +1,56 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cacheobject
+
+import (
+	"github.com/stretchr/testify/require"
+
+	"testing"
+	"time"
+)
+
+func TestExpiresHeaderValueMaxAge(t *testing.T) {
+	now := time.Date(2015, time.October, 21, 16, 29, 0, 0, time.UTC)
+
+	cd, err := ParseResponseCacheControl("max-age=300, s-maxage=600")
+	require.NoError(t, err)
+
+	v, ok := ExpiresHeaderValue(cd, now)
+	require.True(t, ok)
+	require.Equal(t, "Wed, 21 Oct 2015 16:34:00 GMT", v)
+}
+
+func TestExpiresHeaderValueSMaxAge(t *testing.T) {
+	now := time.Date(2015, time.October, 21, 16, 29, 0, 0, time.FixedZone("PDT", -7*60*60))
+
+	cd, err := ParseResponseCacheControl("s-maxage=600")
+	require.NoError(t, err)
+
+	v, ok := ExpiresHeaderValue(cd, now)
+	require.True(t, ok)
+	require.Equal(t, "Wed, 21 Oct 2015 23:39:00 GMT", v)
+}
+
+func TestExpiresHeaderValueNoFreshness(t *testing.T) {
+	cd, err := ParseResponseCacheControl("public")
+	require.NoError(t, err)
+
+	v, ok := ExpiresHeaderValue(cd, time.Now())
+	require.False(t, ok)
+	require.Equal(t, "", v)
+}
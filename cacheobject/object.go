@@ -18,7 +18,12 @@
 package cacheobject
 
 import (
+	"errors"
+	"mime"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -30,18 +35,144 @@ import (
 type Object struct {
 	CacheIsPrivate bool
 
+	// When set, responses to requests with a query string are not given
+	// heuristic freshness: http://tools.ietf.org/html/rfc7234#section-4.2.2
+	DisableHeuristicForQuery bool
+
+	// When set, responses without explicit freshness information are uncachable,
+	// even if their status code is cachable by default, and heuristic freshness
+	// is never applied.
+	DefaultToUncachable bool
+
+	// When set, responses to OPTIONS requests are cachable if they include
+	// explicit freshness information, like responses to POST requests.
+	CachableOPTIONS bool
+
+	// When non-zero, the expiration time is capped at this freshness lifetime,
+	// regardless of the origin's directives.
+	MaxFreshnessLifetime time.Duration
+
+	// When non-zero, a Date header further than this in the future is reported
+	// with ReasonResponseClockSkew.
+	ClockSkewThreshold time.Duration
+
+	// When non-zero, a max-age and Expires header whose freshness lifetimes differ
+	// by more than this are reported with ReasonResponseMaxAgeExpiresDisagree.
+	MaxAgeExpiresThreshold time.Duration
+
+	// Request headers, like Cookie, which make a response uncachable when present.
+	UncachableRequestHeaders []string
+
+	// When set, a response Pragma: no-cache without a Cache-Control header is
+	// treated as Cache-Control: no-cache, for legacy origins.
+	ResponsePragmaNoCache bool
+
+	// When non-zero, responses with a Content-Length larger than this are reported
+	// with ReasonResponseBodyTooLarge. Responses which never have a body are exempt.
+	MaxCachableBodyBytes int64
+
+	// When set, a shared cache reports responses with a Content-Encoding whose
+	// Vary header doesn't include Accept-Encoding with ReasonResponseCompressedWithoutVary.
+	WarnMissingVaryOnContentEncoding bool
+
+	// When set, responses without an ETag or Last-Modified header are reported
+	// with ReasonResponseNoValidator.
+	RequireValidator bool
+
+	// When set, a shared cache reports responses whose Vary header includes
+	// Cookie with ReasonResponseVaryCookie.
+	TreatVaryCookieAsUncachable bool
+
+	// When set, 206 Partial Content responses with a multipart/byteranges body are
+	// reported with ReasonResponseMultipartRange.
+	TreatMultipartRangesAsUncachable bool
+
+	// When not nil, the extension directives the cache implements, like
+	// stale-while-revalidate. Responses with any other extension directive, including
+	// immutable, stale-if-error and stale-while-revalidate, are reported with
+	// ReasonResponseUnknownDirective.
+	KnownExtensions map[string]bool
+
+	// When non-zero, the heuristic freshness lifetime of responses with a status code
+	// cachable by default, but with neither explicit freshness nor a Last-Modified header.
+	DefaultHeuristicTTL time.Duration
+
+	// When set, informational reasons explaining why a response was cachable,
+	// like ReasonInfoPOSTCachableWithFreshness, are emitted into ObjectResults.OutInfoReasons,
+	// see Reason.IsInfo.
+	EmitInfoReasons bool
+
+	// When set, called with a human readable explanation of each step in
+	// CachableObject and ExpirationObject, eg, for debugging.
+	Trace func(format string, args ...interface{})
+
 	RespDirectives         *ResponseCacheDirectives
 	RespHeaders            http.Header
 	RespStatusCode         int
 	RespExpiresHeader      time.Time
 	RespDateHeader         time.Time
 	RespLastModifiedHeader time.Time
+	// Age of the response when it was received, from the Age header. Set it to
+	// the UpdatedAge when evaluating a stored response again.
+	RespAgeHeader time.Duration
 
 	ReqDirectives *RequestCacheDirectives
 	ReqHeaders    http.Header
 	ReqMethod     string
+	ReqURL        *url.URL
+
+	// When not MethodCacheabilityDefault, overrides the cachability of ReqMethod.
+	ReqMethodCacheability MethodCacheability
 
+	// The current time. When zero, ExpirationObject, MustRevalidateBeforeUse and
+	// CanServeStale use time.Now().UTC() each time they are called, without setting it.
 	NowUTC time.Time
+
+	// When both are set, the times the request was sent and its response was received.
+	// ExpirationObject then uses the corrected initial age, which accounts for the
+	// response delay and the Date header, rather than only RespAgeHeader:
+	// http://tools.ietf.org/html/rfc7234#section-4.2.3
+	//
+	// The time since ResponseTime is added to the age, so RespAgeHeader should be left
+	// as it was received when evaluating a stored response again.
+	RequestTime  time.Time
+	ResponseTime time.Time
+}
+
+// Classifies if responses to a request may be cached, based on more than the
+// request method. For example, a gRPC gateway may classify unary calls, which
+// are always POSTs, as cachable.
+type MethodCacheability int
+
+const (
+	// Responses are cachable depending on the request method.
+	MethodCacheabilityDefault MethodCacheability = iota
+
+	// Responses are cachable, as if the request method was GET.
+	MethodCacheabilityCachable
+
+	// Responses are not cachable.
+	MethodCacheabilityUncachable
+)
+
+var (
+	ErrObjectRespDirectives = errors.New("Object is missing `RespDirectives`")
+	ErrObjectReqDirectives  = errors.New("Object is missing `ReqDirectives`")
+)
+
+// LOW LEVEL API: Check that an Object built by hand has the fields set that
+// CachableObject and ExpirationObject rely on. Fields with a default, like a
+// zero NowUTC or a missing RespDateHeader, are not required.
+func (o *Object) Validate() error {
+	if o.RespDirectives == nil {
+		return ErrObjectRespDirectives
+	}
+
+	if o.ReqDirectives == nil {
+		return ErrObjectReqDirectives
+	}
+
+	return nil
 }
 
 // LOW LEVEL API: Represents the results of examining an Object with
@@ -49,15 +180,62 @@ type Object struct {
 //
 // TODO(pquerna): decide if this is a good idea or bad
 type ObjectResults struct {
-	OutReasons        []Reason
+	OutReasons []Reason
+	// Informational reasons, which don't prevent the response from being cached, see Reason.IsInfo.
+	OutInfoReasons    []Reason
 	OutWarnings       []Warning
 	OutExpirationTime time.Time
 	OutErr            error
 }
 
+// LOW LEVEL API: Clears the results so an ObjectResults can be reused, keeping the
+// capacity of OutReasons, OutInfoReasons and OutWarnings. This allows pooling ObjectResults, eg:
+//
+//	var resultsPool = sync.Pool{New: func() interface{} { return &ObjectResults{} }}
+//
+//	rv := resultsPool.Get().(*ObjectResults)
+//	CachableObject(obj, rv) // calls Reset
+//	ExpirationObject(obj, rv)
+//	// ... use rv, without keeping references to its slices ...
+//	resultsPool.Put(rv)
+func (r *ObjectResults) Reset() {
+	r.OutReasons = r.OutReasons[:0]
+	r.OutInfoReasons = r.OutInfoReasons[:0]
+	r.OutWarnings = r.OutWarnings[:0]
+	r.OutExpirationTime = time.Time{}
+	r.OutErr = nil
+}
+
 // LOW LEVEL API: Check if a request is cacheable.
 // This function doesn't reset the passed ObjectResults.
 func CachableRequestObject(obj *Object, rv *ObjectResults) {
+	switch obj.ReqMethodCacheability {
+	case MethodCacheabilityCachable:
+		break
+	case MethodCacheabilityUncachable:
+		rv.OutReasons = append(rv.OutReasons, ReasonRequestMethodUnknown)
+	default:
+		cachableRequestMethod(obj, rv)
+	}
+
+	if obj.ReqDirectives != nil && obj.ReqDirectives.NoStore {
+		rv.OutReasons = append(rv.OutReasons, ReasonRequestNoStore)
+	}
+
+	for _, name := range obj.UncachableRequestHeaders {
+		if obj.ReqHeaders.Get(name) != "" {
+			rv.OutReasons = append(rv.OutReasons, ReasonRequestUncachableHeader)
+			break
+		}
+	}
+
+	// asks for validation on the origin server, and to not contact it.
+	if obj.ReqDirectives != nil && obj.ReqDirectives.OnlyIfCached && obj.ReqDirectives.NoCache {
+		rv.OutReasons = append(rv.OutReasons, ReasonRequestContradictoryDirectives)
+	}
+}
+
+func cachableRequestMethod(obj *Object, rv *ObjectResults) {
 	switch obj.ReqMethod {
 	case "GET":
 		break
@@ -77,7 +255,9 @@ func CachableRequestObject(obj *Object, rv *ObjectResults) {
 		rv.OutReasons = append(rv.OutReasons, ReasonRequestMethodCONNECT)
 
 	case "OPTIONS":
-		rv.OutReasons = append(rv.OutReasons, ReasonRequestMethodOPTIONS)
+		if !obj.CachableOPTIONS {
+			rv.OutReasons = append(rv.OutReasons, ReasonRequestMethodOPTIONS)
+		}
 
 	case "TRACE":
 		rv.OutReasons = append(rv.OutReasons, ReasonRequestMethodTRACE)
@@ -89,10 +269,6 @@ func CachableRequestObject(obj *Object, rv *ObjectResults) {
 	default:
 		rv.OutReasons = append(rv.OutReasons, ReasonRequestMethodUnknown)
 	}
-
-	if obj.ReqDirectives != nil && obj.ReqDirectives.NoStore {
-		rv.OutReasons = append(rv.OutReasons, ReasonRequestNoStore)
-	}
 }
 
 // LOW LEVEL API: Check if a response is cacheable.
@@ -110,8 +286,21 @@ func CachableResponseObject(obj *Object, rv *ObjectResults) {
 	  Content-Location header field that has the same value as the POST's
 	  effective request URI (Section 3.1.4.2).
 	*/
-	if obj.ReqMethod == http.MethodPost && !hasFreshness(obj.RespDirectives, obj.RespHeaders, obj.RespExpiresHeader, obj.CacheIsPrivate) {
-		rv.OutReasons = append(rv.OutReasons, ReasonRequestMethodPOST)
+	if obj.ReqMethodCacheability == MethodCacheabilityDefault && obj.ReqMethod == http.MethodPost {
+		if !hasFreshness(obj.RespDirectives, obj.RespHeaders, obj.RespExpiresHeader, obj.CacheIsPrivate) {
+			rv.OutReasons = append(rv.OutReasons, ReasonRequestMethodPOST)
+		} else if !contentLocationMatches(obj.ReqURL, obj.RespHeaders.Get("Content-Location")) {
+			rv.OutReasons = append(rv.OutReasons, ReasonResponsePOSTContentLocationMismatch)
+		} else if obj.EmitInfoReasons {
+			rv.OutInfoReasons = append(rv.OutInfoReasons, ReasonInfoPOSTCachableWithFreshness)
+		}
+	}
+
+	// eg, a CORS preflight cache, where the origin must opt in with explicit freshness.
+	if obj.ReqMethodCacheability == MethodCacheabilityDefault &&
+		obj.ReqMethod == http.MethodOptions && obj.CachableOPTIONS &&
+		!hasFreshness(obj.RespDirectives, obj.RespHeaders, obj.RespExpiresHeader, obj.CacheIsPrivate) {
+		rv.OutReasons = append(rv.OutReasons, ReasonRequestMethodOPTIONS)
 	}
 
 	// Storing Responses to Authenticated Requests: http://tools.ietf.org/html/rfc7234#section-3.2
@@ -133,6 +322,11 @@ func CachableResponseObject(obj *Object, rv *ObjectResults) {
 		rv.OutReasons = append(rv.OutReasons, ReasonResponseNoStore)
 	}
 
+	// Pragma is only defined for requests, but legacy origins send it on responses: http://tools.ietf.org/html/rfc7234#section-5.4
+	if respPragmaNoCache(obj) {
+		rv.OutInfoReasons = append(rv.OutInfoReasons, ReasonResponsePragmaNoCache)
+	}
+
 	/*
 	   the response either:
 
@@ -152,26 +346,128 @@ func CachableResponseObject(obj *Object, rv *ObjectResults) {
 	     *  contains a public response directive (see Section 5.2.2.5).
 	*/
 
+	if obj.MaxCachableBodyBytes > 0 && !bodilessResponse(obj.ReqMethod, obj.RespStatusCode) {
+		if length, err := strconv.ParseInt(obj.RespHeaders.Get("Content-Length"), 10, 64); err == nil &&
+			length > obj.MaxCachableBodyBytes {
+			rv.OutReasons = append(rv.OutReasons, ReasonResponseBodyTooLarge)
+		}
+	}
+
+	if obj.WarnMissingVaryOnContentEncoding && !obj.CacheIsPrivate && compressedWithoutVary(obj.RespHeaders) {
+		rv.OutReasons = append(rv.OutReasons, ReasonResponseCompressedWithoutVary)
+	}
+
+	// a transforming proxy ignored no-transform: http://tools.ietf.org/html/rfc7231#section-6.3.4
+	if obj.RespStatusCode == http.StatusNonAuthoritativeInfo && obj.RespDirectives.NoTransform {
+		rv.OutReasons = append(rv.OutReasons, ReasonResponseTransformConflict)
+	}
+
+	// the origin claims not to support ranges, but sent a partial response anyway
+	if obj.RespStatusCode == http.StatusPartialContent && acceptRangesNone(obj.RespHeaders) {
+		rv.OutReasons = append(rv.OutReasons, ReasonResponseRangeUnsupported)
+	}
+
+	if len(obj.RespDirectives.InvalidDirectives) > 0 {
+		obj.trace("invalid directives ignored: %v", obj.RespDirectives.InvalidDirectives)
+		if obj.EmitInfoReasons {
+			rv.OutInfoReasons = append(rv.OutInfoReasons, ReasonResponseInvalidDirectiveIgnored)
+		}
+	}
+
+	if obj.KnownExtensions != nil {
+		for _, name := range obj.RespDirectives.extensionNames() {
+			if !obj.KnownExtensions[name] {
+				obj.trace("unknown directive: %s", name)
+				rv.OutInfoReasons = append(rv.OutInfoReasons, ReasonResponseUnknownDirective)
+				break
+			}
+		}
+	}
+
+	if obj.TreatMultipartRangesAsUncachable && obj.RespStatusCode == http.StatusPartialContent &&
+		multipartByteranges(obj.RespHeaders) {
+		rv.OutReasons = append(rv.OutReasons, ReasonResponseMultipartRange)
+	}
+
+	if obj.TreatVaryCookieAsUncachable && !obj.CacheIsPrivate && varyCookie(obj.RespHeaders) {
+		rv.OutReasons = append(rv.OutReasons, ReasonResponseVaryCookie)
+	}
+
+	if obj.RequireValidator && !hasValidator(obj) {
+		rv.OutReasons = append(rv.OutReasons, ReasonResponseNoValidator)
+	}
+
+	// a 304 updates a stored response, it is not stored itself: http://tools.ietf.org/html/rfc7234#section-4.3.4
+	if obj.RespStatusCode == http.StatusNotModified {
+		rv.OutReasons = append(rv.OutReasons, ReasonResponseNotModified)
+		return
+	}
+
+	// 1xx responses, like 101 Switching Protocols, are never cached: http://tools.ietf.org/html/rfc7231#section-6.2
+	if obj.RespStatusCode >= 100 && obj.RespStatusCode <= 199 {
+		rv.OutReasons = append(rv.OutReasons, ReasonResponseInformational)
+		return
+	}
+
+	if (obj.DefaultToUncachable || (obj.DisableHeuristicForQuery && hasQueryString(obj.ReqURL))) &&
+		!hasFreshness(obj.RespDirectives, obj.RespHeaders, obj.RespExpiresHeader, obj.CacheIsPrivate) {
+		/* only heuristic freshness would apply, and the origin didn't ask for it */
+		rv.OutReasons = append(rv.OutReasons, ReasonResponseUncachableByDefault)
+		return
+	}
+
 	if obj.RespHeaders.Get("Expires") != "" ||
 		obj.RespDirectives.MaxAge != -1 ||
 		(obj.RespDirectives.SMaxAge != -1 && !obj.CacheIsPrivate) ||
-		cachableStatusCode(obj.RespStatusCode) ||
 		obj.RespDirectives.Public {
 		/* cachable by default, at least one of the above conditions was true */
 		return
 	}
 
+	if cachableStatusCode(obj.RespStatusCode) {
+		/* cachable by default, but only with a heuristic freshness lifetime */
+		if obj.EmitInfoReasons && len(rv.OutReasons) == 0 {
+			rv.OutInfoReasons = append(rv.OutInfoReasons, ReasonResponseHeuristicOnly)
+		}
+		return
+	}
+
 	rv.OutReasons = append(rv.OutReasons, ReasonResponseUncachableByDefault)
 }
 
 // LOW LEVEL API: Check if a object is cachable.
+// This function resets the passed ObjectResults.
 func CachableObject(obj *Object, rv *ObjectResults) {
-	rv.OutReasons = nil
-	rv.OutWarnings = nil
-	rv.OutErr = nil
+	rv.Reset()
 
 	CachableRequestObject(obj, rv)
 	CachableResponseObject(obj, rv)
+
+	if len(rv.OutReasons) == 0 {
+		obj.trace("cachable: no reasons not to cache")
+	} else {
+		obj.trace("reasons not to cache: %v", rv.OutReasons)
+	}
+}
+
+func (o *Object) trace(format string, args ...interface{}) {
+	if o.Trace != nil {
+		o.Trace(format, args...)
+	}
+}
+
+// Returns the current time, for Objects without NowUTC. Replaced by tests to freeze time.
+var nowUTC = func() time.Time {
+	return time.Now().UTC()
+}
+
+// the time the Object is evaluated at, NowUTC, or the current time when it is zero.
+// NowUTC is never set, so an Object which is kept is evaluated at the current time again.
+func (o *Object) now() time.Time {
+	if o.NowUTC.IsZero() {
+		return nowUTC()
+	}
+	return o.NowUTC
 }
 
 var twentyFourHours = time.Duration(24 * time.Hour)
@@ -185,6 +481,8 @@ func ExpirationObject(obj *Object, rv *ObjectResults) {
 	 *  http://tools.ietf.org/html/rfc7234#section-4.2
 	 */
 
+	now := obj.now()
+
 	/*
 	   o  If the cache is shared and the s-maxage response directive
 	      (Section 5.2.2.9) is present, use its value, or
@@ -200,20 +498,60 @@ func ExpirationObject(obj *Object, rv *ObjectResults) {
 	      Section 4.2.2.
 	*/
 
+	if obj.ClockSkewThreshold > 0 && obj.RespDateHeader.Sub(now) > obj.ClockSkewThreshold {
+		obj.trace("clock skew: Date is %v ahead of now, more than %v", obj.RespDateHeader.Sub(now), obj.ClockSkewThreshold)
+		rv.OutInfoReasons = append(rv.OutInfoReasons, ReasonResponseClockSkew)
+	}
+
+	if obj.MaxAgeExpiresThreshold > 0 && obj.RespDirectives.MaxAge != -1 && !obj.RespExpiresHeader.IsZero() {
+		serverDate := obj.RespDateHeader
+		if serverDate.IsZero() {
+			serverDate = now
+		}
+
+		difference := obj.RespExpiresHeader.Sub(serverDate) - time.Second*time.Duration(obj.RespDirectives.MaxAge)
+		if difference < 0 {
+			difference = -difference
+		}
+
+		if difference > obj.MaxAgeExpiresThreshold {
+			obj.trace("max-age and Expires disagree by %v, using max-age", difference)
+			rv.OutInfoReasons = append(rv.OutInfoReasons, ReasonResponseMaxAgeExpiresDisagree)
+		}
+	}
+
 	var expiresTime time.Time
 
+	// the response may have already spent time in other caches.
+	initialAge := obj.RespAgeHeader
+	if !obj.RequestTime.IsZero() && !obj.ResponseTime.IsZero() {
+		initialAge = UpdatedAge(correctedInitialAge(obj), obj.ResponseTime, now)
+	}
+	if initialAge > 0 {
+		obj.trace("age: %v already spent in other caches", initialAge)
+	}
+
 	if obj.RespDirectives.SMaxAge != -1 && !obj.CacheIsPrivate {
-		expiresTime = obj.NowUTC.Add(time.Second * time.Duration(obj.RespDirectives.SMaxAge))
+		obj.trace("using s-maxage=%d", obj.RespDirectives.SMaxAge)
+		expiresTime = now.Add(time.Second * time.Duration(obj.RespDirectives.SMaxAge))
 	} else if obj.RespDirectives.MaxAge != -1 {
-		expiresTime = obj.NowUTC.UTC().Add(time.Second * time.Duration(obj.RespDirectives.MaxAge))
+		obj.trace("using max-age=%d", obj.RespDirectives.MaxAge)
+		expiresTime = now.Add(time.Second * time.Duration(obj.RespDirectives.MaxAge))
 	} else if !obj.RespExpiresHeader.IsZero() {
 		serverDate := obj.RespDateHeader
 		if serverDate.IsZero() {
 			// common enough case when a Date: header has not yet been added to an
 			// active response.
-			serverDate = obj.NowUTC
+			serverDate = now
 		}
-		expiresTime = obj.NowUTC.Add(obj.RespExpiresHeader.Sub(serverDate))
+		// a Date: header in the future still gives the freshness lifetime, the
+		// origin's clock being ahead of ours only affects the apparent age:
+		// http://tools.ietf.org/html/rfc7234#section-4.2.3
+		obj.trace("using Expires: %v after Date", obj.RespExpiresHeader.Sub(serverDate))
+		expiresTime = now.Add(obj.RespExpiresHeader.Sub(serverDate))
+	} else if obj.DefaultToUncachable || (obj.DisableHeuristicForQuery && hasQueryString(obj.ReqURL)) {
+		// no heuristic freshness, eg, for URLs with a query string
+		obj.trace("heuristic: disabled, no explicit freshness")
 	} else if !obj.RespLastModifiedHeader.IsZero() {
 		// heuristic freshness lifetime
 		rv.OutWarnings = append(rv.OutWarnings, WarningHeuristicExpiration)
@@ -224,26 +562,53 @@ func ExpirationObject(obj *Object, rv *ObjectResults) {
 		//
 		// expiry-period = MIN(time-since-last-modified-date * factor, 24 hours)
 		//
-		// obj.NowUTC
+		// now, the time the Object is evaluated at
 
-		since := obj.RespLastModifiedHeader.Sub(obj.NowUTC)
+		since := obj.RespLastModifiedHeader.Sub(now)
 		since = time.Duration(float64(since) * -0.1)
 
 		if since > twentyFourHours {
-			expiresTime = obj.NowUTC.Add(twentyFourHours)
+			obj.trace("heuristic: 10%% of %v = %v capped to %v", since*10, since, twentyFourHours)
+			expiresTime = now.Add(twentyFourHours)
 		} else {
-			expiresTime = obj.NowUTC.Add(since)
+			obj.trace("heuristic: 10%% of %v = %v", since*10, since)
+			expiresTime = now.Add(since)
 		}
 
 		if debug {
-			println("Now UTC: ", obj.NowUTC.String())
+			println("Now UTC: ", now.String())
 			println("Last-Modified: ", obj.RespLastModifiedHeader.String())
 			println("Since: ", since.String())
 			println("TwentyFourHours: ", twentyFourHours.String())
 			println("Expiration: ", expiresTime.String())
 		}
+	} else if obj.DefaultHeuristicTTL > 0 && cachableStatusCode(obj.RespStatusCode) {
+		rv.OutWarnings = append(rv.OutWarnings, WarningHeuristicExpiration)
+		obj.trace("heuristic: no Last-Modified, using the default of %v", obj.DefaultHeuristicTTL)
+		expiresTime = now.Add(obj.DefaultHeuristicTTL)
 	} else {
 		// TODO(pquerna): what should the default behavior be for expiration time?
+		obj.trace("no explicit freshness and no Last-Modified for heuristic freshness")
+	}
+
+	if !expiresTime.IsZero() {
+		// the freshness lifetime is reduced by the time already spent in other
+		// caches, whether it is explicit or heuristic
+		expiresTime = expiresTime.Add(-initialAge)
+	}
+
+	if obj.EmitInfoReasons && initialAge > 0 && !expiresTime.IsZero() && !expiresTime.After(now) {
+		obj.trace("already stale: Age %v exceeds the freshness lifetime", initialAge)
+		rv.OutInfoReasons = append(rv.OutInfoReasons, ReasonResponseAlreadyStaleOnReceipt)
+	}
+
+	if obj.MaxFreshnessLifetime > 0 && !expiresTime.IsZero() {
+		maxExpiresTime := now.Add(obj.MaxFreshnessLifetime)
+		if expiresTime.After(maxExpiresTime) {
+			obj.trace("freshness capped to %v", obj.MaxFreshnessLifetime)
+			expiresTime = maxExpiresTime
+			rv.OutInfoReasons = append(rv.OutInfoReasons, ReasonResponseFreshnessCapped)
+		}
 	}
 
 	rv.OutExpirationTime = expiresTime
@@ -264,83 +629,177 @@ func UsingRequestResponseWithObject(req *http.Request,
 	statusCode int,
 	respHeaders http.Header,
 	privateCache bool) ([]Reason, time.Time, []Warning, *Object, error) {
+	obj, err := NewObject(req, statusCode, respHeaders, privateCache)
+	if err != nil {
+		return nil, time.Time{}, nil, nil, err
+	}
+
+	rv := ObjectResults{}
+
+	CachableObject(obj, &rv)
+	if rv.OutErr != nil {
+		return nil, time.Time{}, nil, nil, rv.OutErr
+	}
+
+	ExpirationObject(obj, &rv)
+	if rv.OutErr != nil {
+		return nil, time.Time{}, nil, nil, rv.OutErr
+	}
+
+	return rv.OutReasons, rv.OutExpirationTime, rv.OutWarnings, obj, nil
+}
+
+// LOW LEVEL API: Builds an Object from an HTTP request, and parts of the response.
+//
+// The Object is not evaluated, so callers may adjust it before passing it
+// to CachableObject and ExpirationObject.
+func NewObject(req *http.Request,
+	statusCode int,
+	respHeaders http.Header,
+	privateCache bool) (*Object, error) {
+	obj, err := ObjectFromExchange(req, statusCode, respHeaders, nowUTC())
+	if err != nil {
+		return nil, err
+	}
+
+	obj.CacheIsPrivate = privateCache
+	return obj, nil
+}
+
+// LOW LEVEL API: Builds an Object from an HTTP request, and parts of the response,
+// as evaluated at now, eg, when the response was received.
+//
+// The directives and the Date, Expires, Last-Modified and Age headers are parsed,
+// all other fields are left at their defaults, eg, for a shared cache.
+func ObjectFromExchange(req *http.Request,
+	statusCode int,
+	respHeaders http.Header,
+	now time.Time) (*Object, error) {
+	return objectFromExchange(req, statusCode, respHeaders, now, ParseResponseCacheControl)
+}
+
+// LOW LEVEL API: Like ObjectFromExchange, but the response directives are parsed with
+// ParseResponseCacheControlLenient.
+func ObjectFromExchangeLenient(req *http.Request,
+	statusCode int,
+	respHeaders http.Header,
+	now time.Time) (*Object, error) {
+	return objectFromExchange(req, statusCode, respHeaders, now, ParseResponseCacheControlLenient)
+}
+
+// LOW LEVEL API: Like ObjectFromExchange without a request, but with response directives
+// which were already parsed, eg, leniently, or when the response was stored, instead of
+// parsing the Cache-Control header again.
+func ObjectFromDirectives(respDir *ResponseCacheDirectives,
+	statusCode int,
+	respHeaders http.Header,
+	now time.Time) (*Object, error) {
+	return objectFromExchange(nil, statusCode, respHeaders, now, func(string) (*ResponseCacheDirectives, error) {
+		return respDir, nil
+	})
+}
+
+func objectFromExchange(req *http.Request,
+	statusCode int,
+	respHeaders http.Header,
+	now time.Time,
+	parseResponse func(value string) (*ResponseCacheDirectives, error)) (*Object, error) {
 	var reqHeaders http.Header
 	var reqMethod string
+	var reqURL *url.URL
 
 	var reqDir *RequestCacheDirectives = nil
-	respDir, err := ParseResponseCacheControl(respHeaders.Get("Cache-Control"))
+	respDir, err := parseResponse(joinedHeader(respHeaders, "Cache-Control"))
 	if err != nil {
-		return nil, time.Time{}, nil, nil, err
+		return nil, err
 	}
 
 	if req != nil {
-		reqDir, err = ParseRequestCacheControl(req.Header.Get("Cache-Control"))
+		reqDir, err = RequestDirectives(req)
 		if err != nil {
-			return nil, time.Time{}, nil, nil, err
+			return nil, err
 		}
 		reqHeaders = req.Header
 		reqMethod = req.Method
+		reqURL = req.URL
 	}
 
 	var expiresHeader time.Time
 	var dateHeader time.Time
 	var lastModifiedHeader time.Time
 
-	if respHeaders.Get("Expires") != "" {
-		expiresHeader, err = http.ParseTime(respHeaders.Get("Expires"))
+	// When an upstream sends multiple Expires headers, the first valid one is used.
+	for _, v := range respHeaders.Values("Expires") {
+		t, err := parseHTTPDate(v)
 		if err != nil {
 			// sometimes servers will return `Expires: 0` or `Expires: -1` to
 			// indicate expired content
-			expiresHeader = time.Time{}
+			continue
 		}
-		expiresHeader = expiresHeader.UTC()
+		expiresHeader = t.UTC()
+		break
 	}
 
-	if respHeaders.Get("Date") != "" {
-		dateHeader, err = http.ParseTime(respHeaders.Get("Date"))
+	// When an upstream sends multiple Date headers, the last one is used, as the
+	// most recent: http://tools.ietf.org/html/rfc7231#section-7.1.1.2
+	if dates := respHeaders.Values("Date"); len(dates) > 0 {
+		dateHeader, err = parseHTTPDate(dates[len(dates)-1])
 		if err != nil {
-			return nil, time.Time{}, nil, nil, err
+			return nil, err
 		}
 		dateHeader = dateHeader.UTC()
 	}
 
 	if respHeaders.Get("Last-Modified") != "" {
-		lastModifiedHeader, err = http.ParseTime(respHeaders.Get("Last-Modified"))
+		lastModifiedHeader, err = parseHTTPDate(respHeaders.Get("Last-Modified"))
 		if err != nil {
-			return nil, time.Time{}, nil, nil, err
+			return nil, err
 		}
 		lastModifiedHeader = lastModifiedHeader.UTC()
 	}
 
 	obj := Object{
-		CacheIsPrivate: privateCache,
-
 		RespDirectives:         respDir,
 		RespHeaders:            respHeaders,
 		RespStatusCode:         statusCode,
 		RespExpiresHeader:      expiresHeader,
 		RespDateHeader:         dateHeader,
 		RespLastModifiedHeader: lastModifiedHeader,
+		RespAgeHeader:          parseAgeHeader(respHeaders),
 
 		ReqDirectives: reqDir,
 		ReqHeaders:    reqHeaders,
 		ReqMethod:     reqMethod,
+		ReqURL:        reqURL,
 
-		NowUTC: time.Now().UTC(),
+		NowUTC: now.UTC(),
 	}
-	rv := ObjectResults{}
 
-	CachableObject(&obj, &rv)
-	if rv.OutErr != nil {
-		return nil, time.Time{}, nil, nil, rv.OutErr
-	}
+	return &obj, nil
+}
 
-	ExpirationObject(&obj, &rv)
-	if rv.OutErr != nil {
-		return nil, time.Time{}, nil, nil, rv.OutErr
+// Layouts some upstreams use for dates, which http.ParseTime rejects, eg, with
+// a numeric `+0000` zone instead of `GMT`.
+var obsoleteHTTPDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC850,
+}
+
+// parse an HTTP-date: http://tools.ietf.org/html/rfc7231#section-7.1.1.1,
+// tolerating more layouts than http.ParseTime.
+func parseHTTPDate(v string) (time.Time, error) {
+	t, err := http.ParseTime(v)
+	if err == nil {
+		return t, nil
 	}
 
-	return rv.OutReasons, rv.OutExpirationTime, rv.OutWarnings, &obj, nil
+	for _, layout := range obsoleteHTTPDateLayouts {
+		if t, lerr := time.Parse(layout, v); lerr == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
 }
 
 // calculate if a freshness directive is present: http://tools.ietf.org/html/rfc7234#section-4.2.1
@@ -360,6 +819,140 @@ func hasFreshness(respDir *ResponseCacheDirectives, respHeaders http.Header, res
 	return false
 }
 
+// LOW LEVEL API: Check if a response may still be stored once its body has been read, given
+// decl, its declared Content-Length, or -1 if it had none, and observed, the length of the
+// body that was actually received. When they differ, eg, because the download was truncated,
+// it returns false and ReasonResponseLengthMismatch. Otherwise it returns true and ReasonNone.
+//
+// Like MaxCachableBodyBytes, this complements CachableObject, which only sees the headers.
+func CachableWithObservedLength(decl int64, observed int64) (bool, Reason) {
+	if decl >= 0 && decl != observed {
+		return false, ReasonResponseLengthMismatch
+	}
+	return true, ReasonNone
+}
+
+// check if a response never has a body, so its size doesn't matter: http://tools.ietf.org/html/rfc7230#section-3.3.3
+func bodilessResponse(reqMethod string, statusCode int) bool {
+	if reqMethod == http.MethodHead {
+		return true
+	}
+
+	switch statusCode {
+	case http.StatusNoContent, http.StatusNotModified:
+		return true
+	}
+
+	return statusCode >= 100 && statusCode <= 199
+}
+
+// check if a response is encoded, but not selected by the request's Accept-Encoding
+func compressedWithoutVary(respHeaders http.Header) bool {
+	encoding := strings.TrimSpace(respHeaders.Get("Content-Encoding"))
+	if encoding == "" || strings.EqualFold(encoding, "identity") {
+		return false
+	}
+
+	for _, field := range SecondaryKeyFields(respHeaders) {
+		if field == "accept-encoding" || field == VaryAll {
+			return false
+		}
+	}
+	return true
+}
+
+// check if a response varies on the Cookie request header
+func varyCookie(respHeaders http.Header) bool {
+	for _, field := range SecondaryKeyFields(respHeaders) {
+		if field == "cookie" {
+			return true
+		}
+	}
+	return false
+}
+
+// check if an Accept-Ranges header includes none: http://tools.ietf.org/html/rfc7233#section-2.3
+func acceptRangesNone(headers http.Header) bool {
+	for _, value := range headers.Values("Accept-Ranges") {
+		for _, unit := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(unit), "none") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// check if a response carries several ranges: http://tools.ietf.org/html/rfc7233#appendix-A
+func multipartByteranges(headers http.Header) bool {
+	mediaType, _, err := mime.ParseMediaType(headers.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return mediaType == "multipart/byteranges"
+}
+
+// check if a response must be revalidated before each use, because of an unqualified
+// no-cache directive, or a Pragma: no-cache treated as one: http://tools.ietf.org/html/rfc7234#section-5.2.2.2
+//
+// The directives are not modified, they may be shared by a CachedParser.
+func respNoCache(obj *Object) bool {
+	if obj.RespDirectives.NoCachePresent && len(obj.RespDirectives.NoCache) == 0 {
+		return true
+	}
+	return respPragmaNoCache(obj)
+}
+
+// check if a response Pragma: no-cache is treated as Cache-Control: no-cache, see Object.ResponsePragmaNoCache.
+func respPragmaNoCache(obj *Object) bool {
+	return obj.ResponsePragmaNoCache && obj.RespHeaders.Get("Cache-Control") == "" && pragmaNoCache(obj.RespHeaders)
+}
+
+// check if a Pragma header includes no-cache: http://tools.ietf.org/html/rfc7234#section-5.4
+func pragmaNoCache(headers http.Header) bool {
+	for _, value := range headers.Values("Pragma") {
+		for _, directive := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(directive), "no-cache") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// check if a Content-Location header identifies the effective request URI, which
+// allows a cached POST response to be reused: http://tools.ietf.org/html/rfc7231#section-4.3.3
+func contentLocationMatches(reqURL *url.URL, contentLocation string) bool {
+	if reqURL == nil || contentLocation == "" {
+		return false
+	}
+
+	loc, err := reqURL.Parse(contentLocation)
+	if err != nil {
+		return false
+	}
+
+	if reqURL.Host == "" {
+		// server side requests usually only carry the path, so compare the
+		// Content-Location against that.
+		loc.Scheme = ""
+		loc.User = nil
+		loc.Host = ""
+	}
+
+	loc.Fragment = ""
+	return loc.String() == reqURL.String()
+}
+
+// A query string is commonly used to bust caches, so heuristic freshness
+// should not be assumed for it: http://tools.ietf.org/html/rfc7234#section-4.2.2
+func hasQueryString(u *url.URL) bool {
+	if u == nil {
+		return false
+	}
+	return u.RawQuery != "" || u.ForceQuery
+}
+
 func cachableStatusCode(statusCode int) bool {
 	/*
 		Responses with status codes that are defined as cacheable by default
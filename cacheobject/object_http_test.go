@@ -89,3 +89,62 @@ func TestCachableResponseBadExpires(t *testing.T) {
 	require.Len(t, reasons, 0)
 	require.True(t, expires.IsZero())
 }
+
+func TestCachableResponseMultipleDateExpires(t *testing.T) {
+	now := time.Now().UTC()
+
+	respHeaders := http.Header{}
+	respHeaders.Add("Date", now.Add(time.Hour*-1).Format(http.TimeFormat))
+	respHeaders.Add("Date", now.Format(http.TimeFormat))
+	respHeaders.Add("Expires", "0")
+	respHeaders.Add("Expires", now.Add(time.Minute*30).Format(http.TimeFormat))
+	respHeaders.Add("Expires", now.Add(time.Hour*5).Format(http.TimeFormat))
+
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+
+	reasons, expires, _, obj, err := UsingRequestResponseWithObject(req, 200, respHeaders, false)
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+	require.WithinDuration(t, now, obj.RespDateHeader, time.Second*1)
+	require.WithinDuration(t, now.Add(time.Minute*30), obj.RespExpiresHeader, time.Second*1)
+	require.WithinDuration(t, now.Add(time.Minute*30), expires, time.Second*2)
+}
+
+func TestCachableResponseNumericZoneExpires(t *testing.T) {
+	now := time.Now().UTC()
+
+	respHeaders := http.Header{}
+	respHeaders.Set("Date", now.Format(time.RFC1123Z))
+	respHeaders.Set("Expires", now.Add(time.Hour).In(time.FixedZone("", -5*60*60)).Format(time.RFC1123Z))
+
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+
+	reasons, expires, _, obj, err := UsingRequestResponseWithObject(req, 200, respHeaders, false)
+	require.NoError(t, err)
+	require.Len(t, reasons, 0)
+	require.WithinDuration(t, now, obj.RespDateHeader, time.Second*1)
+	require.WithinDuration(t, now.Add(time.Hour), obj.RespExpiresHeader, time.Second*1)
+	require.Equal(t, time.UTC, obj.RespExpiresHeader.Location())
+	require.WithinDuration(t, now.Add(time.Hour), expires, time.Second*2)
+}
+
+func TestParseHTTPDate(t *testing.T) {
+	expected := time.Date(1994, time.November, 6, 8, 49, 37, 0, time.UTC)
+
+	for _, v := range []string{
+		"Sun, 06 Nov 1994 08:49:37 GMT",
+		"Sunday, 06-Nov-94 08:49:37 GMT",
+		"Sun Nov  6 08:49:37 1994",
+		"Sun, 06 Nov 1994 08:49:37 +0000",
+		"Sun, 06 Nov 1994 03:49:37 -0500",
+	} {
+		date, err := parseHTTPDate(v)
+		require.NoError(t, err, v)
+		require.True(t, expected.Equal(date), v)
+	}
+
+	_, err := parseHTTPDate("0")
+	require.Error(t, err)
+}
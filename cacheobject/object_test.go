@@ -20,7 +20,9 @@ package cacheobject
 import (
 	"github.com/stretchr/testify/require"
 
+	"fmt"
 	"net/http"
+	"net/url"
 	"testing"
 	"time"
 )
@@ -37,6 +39,17 @@ func TestCachableStatusCode(t *testing.T) {
 	}
 }
 
+// freezes the time used for Objects without NowUTC, until the returned func is called.
+func freezeNowUTC(now time.Time) func() {
+	orig := nowUTC
+	nowUTC = func() time.Time {
+		return now
+	}
+	return func() {
+		nowUTC = orig
+	}
+}
+
 func fill(t *testing.T, now time.Time) Object {
 	RespDirectives, err := ParseResponseCacheControl("")
 	require.NoError(t, err)
@@ -176,6 +189,8 @@ func TestCachablePOSTExpiresHeader(t *testing.T) {
 
 	obj := fill(t, now)
 	obj.ReqMethod = "POST"
+	obj.ReqURL = &url.URL{Path: "/submit"}
+	obj.RespHeaders.Set("Content-Location", "/submit")
 	obj.RespExpiresHeader = now.Add(time.Hour * 1)
 
 	rv := ObjectResults{}
@@ -189,6 +204,8 @@ func TestCachablePOSTSMax(t *testing.T) {
 
 	obj := fill(t, now)
 	obj.ReqMethod = "POST"
+	obj.ReqURL = &url.URL{Path: "/submit"}
+	obj.RespHeaders.Set("Content-Location", "/submit")
 	obj.RespDirectives.SMaxAge = DeltaSeconds(900)
 
 	rv := ObjectResults{}
@@ -217,6 +234,8 @@ func TestCachablePOSTMax(t *testing.T) {
 
 	obj := fill(t, now)
 	obj.ReqMethod = "POST"
+	obj.ReqURL = &url.URL{Path: "/submit"}
+	obj.RespHeaders.Set("Content-Location", "/submit")
 	obj.RespDirectives.MaxAge = DeltaSeconds(9000)
 
 	rv := ObjectResults{}
@@ -279,6 +298,65 @@ func TestCachableAuthorization(t *testing.T) {
 	require.Len(t, rv.OutReasons, 0)
 }
 
+func TestReqAndRespNoStore(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.ReqDirectives.NoStore = true
+	obj.RespDirectives.NoStore = true
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Equal(t, []Reason{ReasonRequestNoStore, ReasonResponseNoStore}, rv.OutReasons)
+}
+
+func TestAuthorizationPublicNoStore(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.ReqHeaders.Set("Authorization", "bearer random")
+	obj.RespDirectives.Public = true
+	obj.RespDirectives.NoStore = true
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 1)
+	require.Contains(t, rv.OutReasons, ReasonResponseNoStore)
+}
+
+func TestAuthorizationSMaxNoStore(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.ReqHeaders.Set("Authorization", "bearer random")
+	obj.RespDirectives.SMaxAge = DeltaSeconds(300)
+	obj.RespDirectives.MustRevalidate = true
+	obj.RespDirectives.NoStore = true
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 1)
+	require.Contains(t, rv.OutReasons, ReasonResponseNoStore)
+}
+
+func TestAuthorizationPublicPrivate(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.ReqHeaders.Set("Authorization", "bearer random")
+	obj.RespDirectives.Public = true
+	obj.RespDirectives.PrivatePresent = true
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 1)
+	require.Contains(t, rv.OutReasons, ReasonResponsePrivate)
+}
+
 func TestRespNoStore(t *testing.T) {
 	now := time.Now().UTC()
 
@@ -426,3 +504,955 @@ func TestCachableResponseObject(t *testing.T) {
 	CachableResponseObject(&obj, &rv)
 	require.Len(t, rv.OutReasons, 2)
 }
+
+func TestDisableHeuristicForQuery(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.DisableHeuristicForQuery = true
+	obj.ReqURL = &url.URL{Path: "/search", RawQuery: "q=cache"}
+	obj.RespLastModifiedHeader = now.Add(time.Hour * -1)
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 1)
+	require.Contains(t, rv.OutReasons, ReasonResponseUncachableByDefault)
+
+	ExpirationObject(&obj, &rv)
+	require.Len(t, rv.OutWarnings, 0)
+	require.True(t, rv.OutExpirationTime.IsZero())
+}
+
+func TestDisableHeuristicForQueryWithoutQuery(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.DisableHeuristicForQuery = true
+	obj.ReqURL = &url.URL{Path: "/search"}
+	obj.RespLastModifiedHeader = now.Add(time.Hour * -1)
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 0)
+
+	ExpirationObject(&obj, &rv)
+	require.False(t, rv.OutExpirationTime.IsZero())
+}
+
+func TestDisableHeuristicForQueryExplicitFreshness(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.DisableHeuristicForQuery = true
+	obj.ReqURL = &url.URL{Path: "/search", RawQuery: "q=cache"}
+	obj.RespDirectives.MaxAge = DeltaSeconds(60)
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 0)
+
+	ExpirationObject(&obj, &rv)
+	require.WithinDuration(t, now.Add(time.Second*60), rv.OutExpirationTime, time.Second*1)
+}
+
+func TestDisableHeuristicForQueryNilURL(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.DisableHeuristicForQuery = true
+	obj.ReqURL = nil
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 0)
+}
+
+func TestPOSTContentLocation(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.ReqMethod = "POST"
+	obj.ReqURL = &url.URL{Scheme: "http", Host: "example.com", Path: "/submit"}
+	obj.RespDirectives.MaxAge = DeltaSeconds(60)
+	obj.RespHeaders.Set("Content-Location", "http://example.com/submit")
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 0)
+
+	// relative Content-Location is resolved against the request URI
+	obj.RespHeaders.Set("Content-Location", "/submit")
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 0)
+}
+
+func TestPOSTContentLocationMismatch(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.ReqMethod = "POST"
+	obj.ReqURL = &url.URL{Path: "/submit"}
+	obj.RespDirectives.MaxAge = DeltaSeconds(60)
+	obj.RespHeaders.Set("Content-Location", "/results/1")
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 1)
+	require.Contains(t, rv.OutReasons, ReasonResponsePOSTContentLocationMismatch)
+}
+
+func TestPOSTContentLocationAbsent(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.ReqMethod = "POST"
+	obj.ReqURL = &url.URL{Path: "/submit"}
+	obj.RespDirectives.MaxAge = DeltaSeconds(60)
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 1)
+	require.Contains(t, rv.OutReasons, ReasonResponsePOSTContentLocationMismatch)
+}
+
+func TestValidate(t *testing.T) {
+	obj := fill(t, time.Now().UTC())
+	require.NoError(t, obj.Validate())
+}
+
+func TestValidateMissingRespDirectives(t *testing.T) {
+	obj := fill(t, time.Now().UTC())
+	obj.RespDirectives = nil
+	require.Equal(t, ErrObjectRespDirectives, obj.Validate())
+}
+
+func TestValidateMissingReqDirectives(t *testing.T) {
+	obj := fill(t, time.Now().UTC())
+	obj.ReqDirectives = nil
+	require.Equal(t, ErrObjectReqDirectives, obj.Validate())
+}
+
+func TestValidateZeroNowUTC(t *testing.T) {
+	obj := fill(t, time.Now().UTC())
+	obj.NowUTC = time.Time{}
+	require.NoError(t, obj.Validate())
+}
+
+func TestValidateMissingRespDateHeader(t *testing.T) {
+	obj := fill(t, time.Now().UTC())
+	obj.RespDateHeader = time.Time{}
+	require.NoError(t, obj.Validate())
+}
+
+func TestCachableOPTIONSMax(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.ReqMethod = "OPTIONS"
+	obj.CachableOPTIONS = true
+	obj.RespDirectives.MaxAge = DeltaSeconds(600)
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 0)
+
+	ExpirationObject(&obj, &rv)
+	require.WithinDuration(t, now.Add(time.Second*600), rv.OutExpirationTime, time.Second*1)
+}
+
+func TestNonCachableOPTIONSNoFreshness(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.ReqMethod = "OPTIONS"
+	obj.CachableOPTIONS = true
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 1)
+	require.Contains(t, rv.OutReasons, ReasonRequestMethodOPTIONS)
+}
+
+func TestNonCachableOPTIONSDefault(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.ReqMethod = "OPTIONS"
+	obj.RespDirectives.MaxAge = DeltaSeconds(600)
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 1)
+	require.Contains(t, rv.OutReasons, ReasonRequestMethodOPTIONS)
+}
+
+func TestExpirationMaxFreshnessLifetime(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.MaxFreshnessLifetime = time.Hour
+	obj.RespDirectives.MaxAge = DeltaSeconds(86400)
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.Len(t, rv.OutReasons, 0)
+
+	ExpirationObject(&obj, &rv)
+	require.Len(t, rv.OutReasons, 0)
+	require.Equal(t, []Reason{ReasonResponseFreshnessCapped}, rv.OutInfoReasons)
+	require.WithinDuration(t, now.Add(time.Hour), rv.OutExpirationTime, time.Second*1)
+}
+
+func TestExpirationMaxFreshnessLifetimeNotReached(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.MaxFreshnessLifetime = time.Hour
+	obj.RespDirectives.MaxAge = DeltaSeconds(60)
+
+	rv := ObjectResults{}
+	ExpirationObject(&obj, &rv)
+	require.Len(t, rv.OutReasons, 0)
+	require.WithinDuration(t, now.Add(time.Second*60), rv.OutExpirationTime, time.Second*1)
+}
+
+func TestMethodCacheabilityCachablePOST(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.ReqMethod = "POST"
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.Len(t, rv.OutReasons, 1)
+	require.Contains(t, rv.OutReasons, ReasonRequestMethodPOST)
+
+	obj.ReqMethodCacheability = MethodCacheabilityCachable
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 0)
+}
+
+func TestMethodCacheabilityUncachableGET(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.ReqMethodCacheability = MethodCacheabilityUncachable
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 1)
+	require.Contains(t, rv.OutReasons, ReasonRequestMethodUnknown)
+}
+
+func TestNowUTCDefault(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.NowUTC = time.Time{}
+	obj.RespDirectives.MaxAge = DeltaSeconds(60)
+
+	rv := ObjectResults{}
+	ExpirationObject(&obj, &rv)
+	require.True(t, obj.NowUTC.IsZero())
+	require.WithinDuration(t, time.Now().UTC().Add(time.Second*60), rv.OutExpirationTime, time.Second*10)
+}
+
+func TestNowUTCDefaultFrozen(t *testing.T) {
+	now := time.Date(2015, time.October, 21, 16, 29, 0, 0, time.UTC)
+	defer freezeNowUTC(now)()
+
+	obj := fill(t, now)
+	obj.NowUTC = time.Time{}
+	obj.RespDirectives.MaxAge = DeltaSeconds(60)
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.True(t, obj.NowUTC.IsZero())
+
+	ExpirationObject(&obj, &rv)
+	require.Equal(t, now.Add(time.Second*60), rv.OutExpirationTime)
+}
+
+func TestNowUTCDefaultReused(t *testing.T) {
+	now := time.Date(2015, time.October, 21, 16, 29, 0, 0, time.UTC)
+	restore := freezeNowUTC(now)
+
+	obj := fill(t, now)
+	obj.NowUTC = time.Time{}
+	obj.RespDirectives.MaxAge = DeltaSeconds(60)
+
+	rv := ObjectResults{}
+	ExpirationObject(&obj, &rv)
+	require.Equal(t, now.Add(time.Second*60), rv.OutExpirationTime)
+	restore()
+
+	later := now.Add(time.Hour)
+	defer freezeNowUTC(later)()
+
+	ExpirationObject(&obj, &rv)
+	require.Equal(t, later.Add(time.Second*60), rv.OutExpirationTime)
+}
+
+func TestNowUTCDefaultReuse(t *testing.T) {
+	now := time.Date(2015, time.October, 21, 16, 29, 0, 0, time.UTC)
+	defer freezeNowUTC(now)()
+
+	obj := fill(t, now)
+	obj.NowUTC = time.Time{}
+	obj.RespDirectives.StaleWhileRevalidate = DeltaSeconds(60)
+
+	require.False(t, MustRevalidateBeforeUse(&obj, now.Add(time.Second)))
+	require.True(t, MustRevalidateBeforeUse(&obj, now))
+	require.False(t, CanServeStale(&obj, now.Add(time.Second)))
+	require.True(t, CanServeStale(&obj, now.Add(-time.Second*30)))
+	require.True(t, obj.NowUTC.IsZero())
+}
+
+func TestExpirationExpiresFutureDate(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespDateHeader = now.Add(time.Minute)
+	obj.RespExpiresHeader = now.Add(time.Minute + time.Hour)
+
+	rv := ObjectResults{}
+	ExpirationObject(&obj, &rv)
+	require.Len(t, rv.OutReasons, 0)
+	require.WithinDuration(t, now.Add(time.Hour), rv.OutExpirationTime, time.Second*1)
+}
+
+func TestExpirationClockSkewThreshold(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.ClockSkewThreshold = time.Second * 30
+	obj.RespDateHeader = now.Add(time.Minute)
+	obj.RespDirectives.MaxAge = DeltaSeconds(60)
+
+	rv := ObjectResults{}
+	ExpirationObject(&obj, &rv)
+	require.Len(t, rv.OutReasons, 0)
+	require.Equal(t, []Reason{ReasonResponseClockSkew}, rv.OutInfoReasons)
+
+	obj.ClockSkewThreshold = time.Minute * 5
+	rv = ObjectResults{}
+	ExpirationObject(&obj, &rv)
+	require.Len(t, rv.OutInfoReasons, 0)
+}
+
+func TestResp304(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespStatusCode = 304
+	obj.RespDirectives.MaxAge = DeltaSeconds(60)
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.Len(t, rv.OutReasons, 1)
+	require.Contains(t, rv.OutReasons, ReasonResponseNotModified)
+}
+
+func TestResp100(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespStatusCode = 100
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.Len(t, rv.OutReasons, 1)
+	require.NotContains(t, rv.OutReasons, ReasonResponseNotModified)
+	require.Contains(t, rv.OutReasons, ReasonResponseInformational)
+}
+
+func TestResp101(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespStatusCode = 101
+	obj.RespHeaders.Set("Upgrade", "websocket")
+	obj.RespDirectives.Public = true
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.Len(t, rv.OutReasons, 1)
+	require.Contains(t, rv.OutReasons, ReasonResponseInformational)
+}
+
+func TestReqOnlyIfCachedNoCache(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.ReqDirectives.OnlyIfCached = true
+	obj.ReqDirectives.NoCache = true
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.Len(t, rv.OutReasons, 1)
+	require.Contains(t, rv.OutReasons, ReasonRequestContradictoryDirectives)
+}
+
+func TestReqOnlyIfCachedOrNoCache(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.ReqDirectives.OnlyIfCached = true
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.Len(t, rv.OutReasons, 0)
+
+	obj.ReqDirectives.OnlyIfCached = false
+	obj.ReqDirectives.NoCache = true
+
+	CachableObject(&obj, &rv)
+	require.Len(t, rv.OutReasons, 0)
+}
+
+func TestResp405Heuristic(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespStatusCode = 405
+	obj.RespHeaders.Set("Allow", "GET, HEAD")
+	obj.RespLastModifiedHeader = now.Add(time.Hour * -10)
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.Len(t, rv.OutReasons, 0)
+
+	ExpirationObject(&obj, &rv)
+	require.Len(t, rv.OutReasons, 0)
+	require.Contains(t, rv.OutWarnings, WarningHeuristicExpiration)
+	require.WithinDuration(t, now.Add(time.Hour), rv.OutExpirationTime, time.Second*1)
+}
+
+func TestUncachableRequestHeaders(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.UncachableRequestHeaders = []string{"Cookie", "X-Api-Key"}
+	obj.RespDirectives.Public = true
+	obj.RespDirectives.MaxAge = DeltaSeconds(60)
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.Len(t, rv.OutReasons, 0)
+
+	obj.ReqHeaders.Set("Cookie", "session=1")
+	CachableObject(&obj, &rv)
+	require.Len(t, rv.OutReasons, 1)
+	require.Contains(t, rv.OutReasons, ReasonRequestUncachableHeader)
+
+	obj.ReqHeaders.Set("X-Api-Key", "secret")
+	CachableObject(&obj, &rv)
+	require.Len(t, rv.OutReasons, 1)
+}
+
+func TestResponsePragmaNoCacheRevalidates(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.ResponsePragmaNoCache = true
+	obj.RespHeaders.Set("Pragma", "no-cache")
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 0)
+	require.Equal(t, []Reason{ReasonResponsePragmaNoCache}, rv.OutInfoReasons)
+	require.False(t, obj.RespDirectives.NoCachePresent)
+	require.True(t, MustRevalidateBeforeUse(&obj, now.Add(time.Hour)))
+	require.False(t, CanServeStale(&obj, now.Add(-time.Hour)))
+}
+
+func TestResponsePragmaNoCacheSharedDirectives(t *testing.T) {
+	now := time.Now().UTC()
+	parser := NewCachedParser(8)
+
+	respDir, err := parser.ParseResponseCacheControl("")
+	require.NoError(t, err)
+
+	obj := fill(t, now)
+	obj.RespDirectives = respDir
+	obj.ResponsePragmaNoCache = true
+	obj.RespHeaders.Set("Pragma", "no-cache")
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.Len(t, rv.OutReasons, 0)
+	require.Equal(t, []Reason{ReasonResponsePragmaNoCache}, rv.OutInfoReasons)
+
+	respDir, err = parser.ParseResponseCacheControl("")
+	require.NoError(t, err)
+	require.False(t, respDir.NoCachePresent)
+}
+
+func TestMaxCachableBodyBytes(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.MaxCachableBodyBytes = 1024
+	obj.RespHeaders.Set("Content-Length", "2048")
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Equal(t, []Reason{ReasonResponseBodyTooLarge}, rv.OutReasons)
+
+	obj.RespHeaders.Set("Content-Length", "1024")
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 0)
+}
+
+func TestMaxCachableBodyBytesNoContent(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.MaxCachableBodyBytes = 1024
+	obj.RespStatusCode = http.StatusNoContent
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 0)
+
+	obj.RespHeaders.Set("Content-Length", "2048")
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 0)
+}
+
+func TestMaxCachableBodyBytesNotModified(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.MaxCachableBodyBytes = 1024
+	obj.RespStatusCode = http.StatusNotModified
+	obj.RespHeaders.Set("Content-Length", "2048")
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Equal(t, []Reason{ReasonResponseNotModified}, rv.OutReasons)
+}
+
+func TestMaxCachableBodyBytesHEAD(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.MaxCachableBodyBytes = 1024
+	obj.ReqMethod = http.MethodHead
+	obj.RespHeaders.Set("Content-Length", "2048")
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 0)
+}
+
+func TestObjectResultsReset(t *testing.T) {
+	rv := ObjectResults{
+		OutReasons:        make([]Reason, 2, 8),
+		OutInfoReasons:    make([]Reason, 1, 2),
+		OutWarnings:       make([]Warning, 1, 4),
+		OutExpirationTime: time.Now().UTC(),
+		OutErr:            ErrObjectRespDirectives,
+	}
+
+	rv.Reset()
+	require.Len(t, rv.OutReasons, 0)
+	require.Equal(t, 8, cap(rv.OutReasons))
+	require.Len(t, rv.OutInfoReasons, 0)
+	require.Equal(t, 2, cap(rv.OutInfoReasons))
+	require.Len(t, rv.OutWarnings, 0)
+	require.Equal(t, 4, cap(rv.OutWarnings))
+	require.True(t, rv.OutExpirationTime.IsZero())
+	require.NoError(t, rv.OutErr)
+}
+
+func TestObjectResultsReuse(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespDirectives.NoStore = true
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.Equal(t, []Reason{ReasonResponseNoStore}, rv.OutReasons)
+
+	obj.RespDirectives.NoStore = false
+	CachableObject(&obj, &rv)
+	require.Len(t, rv.OutReasons, 0)
+	require.Equal(t, 1, cap(rv.OutReasons))
+}
+
+func TestCompressedWithoutVary(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.WarnMissingVaryOnContentEncoding = true
+	obj.RespHeaders.Set("Content-Encoding", "gzip")
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Equal(t, []Reason{ReasonResponseCompressedWithoutVary}, rv.OutReasons)
+
+	obj.RespHeaders.Set("Vary", "Origin")
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Equal(t, []Reason{ReasonResponseCompressedWithoutVary}, rv.OutReasons)
+}
+
+func TestCompressedWithVary(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.WarnMissingVaryOnContentEncoding = true
+	obj.RespHeaders.Set("Content-Encoding", "gzip")
+	obj.RespHeaders.Set("Vary", "Origin, accept-encoding")
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 0)
+}
+
+func TestCompressedWithoutVaryPrivateCache(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.CacheIsPrivate = true
+	obj.WarnMissingVaryOnContentEncoding = true
+	obj.RespHeaders.Set("Content-Encoding", "gzip")
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 0)
+}
+
+func TestUncompressedWithoutVary(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.WarnMissingVaryOnContentEncoding = true
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 0)
+
+	obj.RespHeaders.Set("Content-Encoding", "identity")
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 0)
+}
+
+func TestTraceHeuristic(t *testing.T) {
+	now := time.Now().UTC()
+
+	lines := []string{}
+	obj := fill(t, now)
+	obj.RespLastModifiedHeader = now.Add(time.Hour * -100)
+	obj.Trace = func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	ExpirationObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Equal(t, []string{
+		"cachable: no reasons not to cache",
+		"heuristic: 10% of 100h0m0s = 10h0m0s",
+	}, lines)
+}
+
+func TestTraceHeuristicCapped(t *testing.T) {
+	now := time.Now().UTC()
+
+	lines := []string{}
+	obj := fill(t, now)
+	obj.RespLastModifiedHeader = now.Add(time.Hour * -1000)
+	obj.Trace = func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+
+	rv := ObjectResults{}
+	ExpirationObject(&obj, &rv)
+	require.Equal(t, []string{"heuristic: 10% of 1000h0m0s = 100h0m0s capped to 24h0m0s"}, lines)
+}
+
+func TestTraceSMaxAge(t *testing.T) {
+	now := time.Now().UTC()
+
+	lines := []string{}
+	obj := fill(t, now)
+	obj.RespDirectives.SMaxAge = DeltaSeconds(60)
+	obj.RespDirectives.NoStore = true
+	obj.Trace = func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	ExpirationObject(&obj, &rv)
+	require.Equal(t, []string{
+		"reasons not to cache: [ReasonResponseNoStore]",
+		"using s-maxage=60",
+	}, lines)
+}
+
+func TestNonAuthoritativeNoTransform(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespStatusCode = http.StatusNonAuthoritativeInfo
+	obj.RespDirectives.NoTransform = true
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Equal(t, []Reason{ReasonResponseTransformConflict}, rv.OutReasons)
+}
+
+func TestNonAuthoritative(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespStatusCode = http.StatusNonAuthoritativeInfo
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 0)
+}
+
+func TestMaxAgeExpiresAgree(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.MaxAgeExpiresThreshold = time.Minute
+	obj.RespDirectives.MaxAge = DeltaSeconds(3600)
+	obj.RespExpiresHeader = now.Add(time.Hour + time.Second*30)
+
+	rv := ObjectResults{}
+	ExpirationObject(&obj, &rv)
+	require.Len(t, rv.OutReasons, 0)
+	require.WithinDuration(t, now.Add(time.Hour), rv.OutExpirationTime, time.Second)
+}
+
+func TestMaxAgeExpiresDisagree(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespDirectives.MaxAge = DeltaSeconds(3600)
+	obj.RespExpiresHeader = now.Add(time.Hour * -1)
+
+	rv := ObjectResults{}
+	ExpirationObject(&obj, &rv)
+	require.Len(t, rv.OutReasons, 0)
+
+	obj.MaxAgeExpiresThreshold = time.Minute
+	rv = ObjectResults{}
+	ExpirationObject(&obj, &rv)
+	require.Len(t, rv.OutReasons, 0)
+	require.Equal(t, []Reason{ReasonResponseMaxAgeExpiresDisagree}, rv.OutInfoReasons)
+	require.WithinDuration(t, now.Add(time.Hour), rv.OutExpirationTime, time.Second)
+}
+
+func TestExpirationObjectStaleBoundary(t *testing.T) {
+	now := time.Date(2015, time.October, 21, 16, 29, 0, 0, time.UTC)
+
+	// ExpirationObject must only depend on obj.NowUTC once it is set, the
+	// current time is frozen far from it, so using it would fail the test.
+	defer freezeNowUTC(now.Add(time.Hour * -24))()
+
+	obj := fill(t, now)
+	obj.RespDirectives.MaxAge = DeltaSeconds(60)
+
+	rv := ObjectResults{}
+	ExpirationObject(&obj, &rv)
+	require.Equal(t, now.Add(time.Minute), rv.OutExpirationTime)
+
+	for _, offset := range []time.Duration{0, time.Second * 30, time.Minute - time.Nanosecond} {
+		obj.NowUTC = now.Add(offset)
+		require.False(t, MustRevalidateBeforeUse(&obj, rv.OutExpirationTime), "fresh at %v", offset)
+	}
+
+	for _, offset := range []time.Duration{time.Minute, time.Minute + time.Nanosecond, time.Hour} {
+		obj.NowUTC = now.Add(offset)
+		require.True(t, MustRevalidateBeforeUse(&obj, rv.OutExpirationTime), "stale at %v", offset)
+	}
+
+	// the expiration time is relative to NowUTC when the response is received
+	obj.NowUTC = now.Add(time.Hour)
+	ExpirationObject(&obj, &rv)
+	require.Equal(t, now.Add(time.Hour+time.Minute), rv.OutExpirationTime)
+}
+
+func TestCachablePOSTInfoReason(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.ReqMethod = "POST"
+	obj.ReqURL = &url.URL{Path: "/submit"}
+	obj.RespHeaders.Set("Content-Location", "/submit")
+	obj.RespDirectives.MaxAge = DeltaSeconds(60)
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 0)
+
+	obj.EmitInfoReasons = true
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 0)
+	require.Equal(t, []Reason{ReasonInfoPOSTCachableWithFreshness}, rv.OutInfoReasons)
+
+	obj.RespDirectives.MaxAge = DeltaSeconds(-1)
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Equal(t, []Reason{ReasonRequestMethodPOST}, rv.OutReasons)
+}
+
+func TestPartialContentAcceptRangesBytes(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespStatusCode = http.StatusPartialContent
+	obj.RespHeaders.Set("Accept-Ranges", "bytes")
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 0)
+}
+
+func TestPartialContentAcceptRangesNone(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespStatusCode = http.StatusPartialContent
+	obj.RespHeaders.Set("Accept-Ranges", "None")
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Equal(t, []Reason{ReasonResponseRangeUnsupported}, rv.OutReasons)
+}
+
+func TestPartialContentNoAcceptRanges(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespStatusCode = http.StatusPartialContent
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 0)
+}
+
+func TestHeuristicOnly(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.EmitInfoReasons = true
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 0)
+	require.Equal(t, []Reason{ReasonResponseHeuristicOnly}, rv.OutInfoReasons)
+
+	obj.EmitInfoReasons = false
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 0)
+	require.Len(t, rv.OutInfoReasons, 0)
+}
+
+func TestHeuristicOnlyMaxAge(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.EmitInfoReasons = true
+	obj.RespDirectives.MaxAge = DeltaSeconds(60)
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 0)
+}
+
+func TestPartialContentSingleRange(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.TreatMultipartRangesAsUncachable = true
+	obj.RespStatusCode = http.StatusPartialContent
+	obj.RespHeaders.Set("Content-Type", "text/plain")
+	obj.RespHeaders.Set("Content-Range", "bytes 0-99/1000")
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 0)
+}
+
+func TestPartialContentMultipartRange(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespStatusCode = http.StatusPartialContent
+	obj.RespHeaders.Set("Content-Type", "multipart/byteranges; boundary=THIS_STRING_SEPARATES")
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 0)
+
+	obj.TreatMultipartRangesAsUncachable = true
+	CachableObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Equal(t, []Reason{ReasonResponseMultipartRange}, rv.OutReasons)
+}
+
+func TestCachableWithObservedLength(t *testing.T) {
+	cachable, reason := CachableWithObservedLength(1024, 1024)
+	require.True(t, cachable)
+	require.Equal(t, ReasonNone, reason)
+
+	// without a Content-Length, any length is complete
+	cachable, _ = CachableWithObservedLength(-1, 512)
+	require.True(t, cachable)
+}
+
+func TestCachableWithObservedLengthMismatch(t *testing.T) {
+	cachable, reason := CachableWithObservedLength(1024, 512)
+	require.False(t, cachable)
+	require.Equal(t, ReasonResponseLengthMismatch, reason)
+
+	cachable, reason = CachableWithObservedLength(1024, 2048)
+	require.False(t, cachable)
+	require.Equal(t, ReasonResponseLengthMismatch, reason)
+}
@@ -0,0 +1,110 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cacheobject
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// LOW LEVEL API: Check if a Range request can be satisfied by slicing a complete stored
+// response, rather than forwarding it to the origin: http://tools.ietf.org/html/rfc7233#section-3.1
+//
+// The stored response must be a 200 with a valid Content-Length, and reqRange must be a
+// valid `bytes` range set with at least one range which is satisfiable for that length.
+// Ranges past the end of the body are ignored, like an origin server would.
+func CanSatisfyRangeFromFull(reqRange string, storedStatus int, storedHeaders http.Header) bool {
+	if storedStatus != http.StatusOK {
+		return false
+	}
+
+	length, err := strconv.ParseInt(storedHeaders.Get("Content-Length"), 10, 64)
+	if err != nil || length < 0 {
+		return false
+	}
+
+	reqRange = strings.TrimSpace(reqRange)
+	if len(reqRange) < len("bytes=") || !strings.EqualFold(reqRange[:len("bytes=")], "bytes=") {
+		return false
+	}
+
+	satisfiable := false
+	for _, spec := range strings.Split(reqRange[len("bytes="):], ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		first, last, ok := parseByteRangeSpec(spec)
+		if !ok {
+			return false
+		}
+
+		if first == -1 {
+			// suffix-byte-range-spec, eg, the last 500 bytes
+			satisfiable = satisfiable || (last > 0 && length > 0)
+		} else {
+			satisfiable = satisfiable || first < length
+		}
+	}
+
+	return satisfiable
+}
+
+// parses a byte-range-spec, like `0-499` or `500-`, or a suffix-byte-range-spec, like
+// `-500`, for which first is -1: http://tools.ietf.org/html/rfc7233#section-2.1
+func parseByteRangeSpec(spec string) (first int64, last int64, ok bool) {
+	i := strings.IndexByte(spec, '-')
+	if i == -1 {
+		return 0, 0, false
+	}
+
+	firstPos := strings.TrimSpace(spec[:i])
+	lastPos := strings.TrimSpace(spec[i+1:])
+
+	if firstPos == "" {
+		last, err := parseBytePos(lastPos)
+		if err != nil {
+			return 0, 0, false
+		}
+		return -1, last, true
+	}
+
+	first, err := parseBytePos(firstPos)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	if lastPos == "" {
+		return first, -1, true
+	}
+
+	last, err = parseBytePos(lastPos)
+	if err != nil || last < first {
+		return 0, 0, false
+	}
+	return first, last, true
+}
+
+func parseBytePos(v string) (int64, error) {
+	if v == "" || v[0] == '+' {
+		return 0, strconv.ErrSyntax
+	}
+	return strconv.ParseInt(v, 10, 64)
+}
@@ -0,0 +1,60 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cacheobject
+
+import (
+	"github.com/stretchr/testify/require"
+
+	"net/http"
+	"testing"
+)
+
+func TestCanSatisfyRangeFromFull(t *testing.T) {
+	stored := http.Header{"Content-Length": {"1000"}}
+
+	require.True(t, CanSatisfyRangeFromFull("bytes=0-499", http.StatusOK, stored))
+	require.True(t, CanSatisfyRangeFromFull("bytes=500-", http.StatusOK, stored))
+	require.True(t, CanSatisfyRangeFromFull("bytes=-500", http.StatusOK, stored))
+	require.True(t, CanSatisfyRangeFromFull("Bytes=0-0, 999-999", http.StatusOK, stored))
+
+	// the last byte position is clamped to the length of the body
+	require.True(t, CanSatisfyRangeFromFull("bytes=900-1999", http.StatusOK, stored))
+}
+
+func TestCanSatisfyRangeFromFullOutOfBounds(t *testing.T) {
+	stored := http.Header{"Content-Length": {"1000"}}
+
+	require.False(t, CanSatisfyRangeFromFull("bytes=1000-1999", http.StatusOK, stored))
+	require.False(t, CanSatisfyRangeFromFull("bytes=2000-", http.StatusOK, stored))
+	require.False(t, CanSatisfyRangeFromFull("bytes=-0", http.StatusOK, stored))
+}
+
+func TestCanSatisfyRangeFromFullInvalid(t *testing.T) {
+	stored := http.Header{"Content-Length": {"1000"}}
+
+	require.False(t, CanSatisfyRangeFromFull("", http.StatusOK, stored))
+	require.False(t, CanSatisfyRangeFromFull("items=0-10", http.StatusOK, stored))
+	require.False(t, CanSatisfyRangeFromFull("bytes=10-0", http.StatusOK, stored))
+	require.False(t, CanSatisfyRangeFromFull("bytes=abc", http.StatusOK, stored))
+	require.False(t, CanSatisfyRangeFromFull("bytes=0-10, x-y", http.StatusOK, stored))
+}
+
+func TestCanSatisfyRangeFromFullNotStored(t *testing.T) {
+	require.False(t, CanSatisfyRangeFromFull("bytes=0-499", http.StatusPartialContent, http.Header{"Content-Length": {"1000"}}))
+	require.False(t, CanSatisfyRangeFromFull("bytes=0-499", http.StatusOK, http.Header{}))
+}
@@ -17,16 +17,28 @@
 
 package cacheobject
 
+import (
+	"net/http"
+)
+
 // Repersents a potential Reason to not cache an object.
 //
 // Applications may wish to ignore specific reasons, which will make them non-RFC
 // compliant, but this type gives them specific cases they can choose to ignore,
 // making them compliant in as many cases as they can.
+//
+// The numeric value of a Reason may change between versions, as new reasons are
+// added. Applications which persist reasons, eg, in logs, should use String(),
+// which is stable.
 type Reason int
 
+// ReasonNone is not a reason a response should not be cached, it is returned alongside
+// true by functions like CachableWithObservedLength, when there is no such reason.
+const ReasonNone Reason = -1
+
 const (
 
-	// The request method was POST and an Expiration header was not supplied.
+	// The request method was POST and an Expiration header was not supplied: http://tools.ietf.org/html/rfc7231#section-4.3.3
 	ReasonRequestMethodPOST Reason = iota
 
 	// The request method was PUT and PUTs are not cachable.
@@ -38,16 +50,21 @@ const (
 	// The request method was CONNECT and CONNECTs are not cachable.
 	ReasonRequestMethodCONNECT
 
-	// The request method was OPTIONS and OPTIONS are not cachable.
+	// The request method was OPTIONS and OPTIONS are not cachable, or OPTIONS
+	// were allowed and an Expiration header was not supplied.
 	ReasonRequestMethodOPTIONS
 
 	// The request method was TRACE and TRACEs are not cachable.
 	ReasonRequestMethodTRACE
 
-	// The request method was not recognized by cachecontrol, and should not be cached.
+	// The request method was not recognized by cachecontrol, or was classified as
+	// uncachable, and should not be cached.
 	ReasonRequestMethodUnknown
 
 	// The request included an Cache-Control: no-store header
+	//
+	// Neither the response nor the request may be stored, eg, by caches which also
+	// log requests: http://tools.ietf.org/html/rfc7234#section-5.2.1.5, see MustNotStoreRequest.
 	ReasonRequestNoStore
 
 	// The request included an Authorization header without an explicit Public or Expiration time: http://tools.ietf.org/html/rfc7234#section-3.2
@@ -61,10 +78,182 @@ const (
 
 	// The response failed to meet at least one of the conditions specified in RFC 7234 section 3: http://tools.ietf.org/html/rfc7234#section-3
 	ReasonResponseUncachableByDefault
+
+	// The request included an Cache-Control: only-if-cached header, and no stored response
+	// could satisfy it: http://tools.ietf.org/html/rfc7234#section-5.2.1.7
+	//
+	// This is not emitted by CachableObject, it is for caches that look up stored responses.
+	ReasonRequestOnlyIfCached
+
+	// The response was a 304 Not Modified, which updates a stored response rather than being stored: http://tools.ietf.org/html/rfc7234#section-4.3.4
+	ReasonResponseNotModified
+
+	// The request included both Cache-Control: only-if-cached and no-cache, which contradict each other
+	ReasonRequestContradictoryDirectives
+
+	// The request included a header the cache was configured to treat as uncachable, like Cookie
+	ReasonRequestUncachableHeader
+
+	// The response had an informational 1xx status code, like 101 Switching Protocols, and is never cached
+	ReasonResponseInformational
+
+	// The response's Content-Length was larger than the cache's maximum cachable body size
+	ReasonResponseBodyTooLarge
+
+	// The request method was POST and the response had explicit freshness, but its Content-Location
+	// header was missing or did not match the request URI: http://tools.ietf.org/html/rfc7231#section-4.3.3
+	ReasonResponsePOSTContentLocationMismatch
+
+	// The response had a Content-Encoding, like gzip, but its Vary header did not include
+	// Accept-Encoding, so a shared cache could serve it to clients which can't decode it
+	ReasonResponseCompressedWithoutVary
+
+	// The response had neither an ETag nor a Last-Modified header, so it can't be revalidated
+	// with a conditional request, and the cache was configured to require a validator
+	ReasonResponseNoValidator
+
+	// The response was a 203 Non-Authoritative Information, meaning a proxy transformed it, but it
+	// included Cache-Control: no-transform, which forbids that: http://tools.ietf.org/html/rfc7234#section-5.2.2.4
+	ReasonResponseTransformConflict
+
+	// The response's Vary header included Cookie, which differs for every user, so a shared cache
+	// could not reuse it, and the cache was configured to treat it as uncachable
+	ReasonResponseVaryCookie
+
+	// The response was a 206 Partial Content, but it included Accept-Ranges: none, which contradicts
+	// it, so the origin's range support can't be relied on: http://tools.ietf.org/html/rfc7233#section-2.3
+	ReasonResponseRangeUnsupported
+
+	// The response was a 206 Partial Content with a multipart/byteranges body, which carries
+	// several ranges that are complex to combine with a stored response, and the cache was
+	// configured to treat it as uncachable: http://tools.ietf.org/html/rfc7233#section-4.1
+	ReasonResponseMultipartRange
+
+	// The response body's length differed from its Content-Length, eg, because the download was
+	// truncated, so the stored response would be incomplete: http://tools.ietf.org/html/rfc7234#section-3.1
+	//
+	// This is not emitted by CachableObject, see CachableWithObservedLength.
+	ReasonResponseLengthMismatch
+)
+
+// Informational reasons explain how a response was cached, and never prevent it from being
+// cached. They are only emitted when requested, eg, with Object.EmitInfoReasons, or with the
+// option that enables them, like Object.MaxFreshnessLifetime, and are returned separately,
+// in ObjectResults.OutInfoReasons. They are numbered separately from the other reasons, see IsInfo.
+const reasonInfoBase Reason = 1 << 16
+
+const (
+	// The request method was POST, and the response was cachable because it had explicit
+	// freshness and a matching Content-Location: http://tools.ietf.org/html/rfc7231#section-4.3.3
+	ReasonInfoPOSTCachableWithFreshness Reason = reasonInfoBase + iota
+
+	// The response had no explicit freshness and no public directive, it was only cachable because
+	// its status code is cachable by default, so its freshness lifetime will be heuristic.
+	// It is not emitted when other reasons were found:
+	// http://tools.ietf.org/html/rfc7234#section-4.2.2
+	ReasonResponseHeuristicOnly
+
+	// The freshness lifetime of the response was capped by the cache's maximum freshness lifetime.
+	//
+	// This reason is informational, the response may still be cached until the capped expiration time.
+	ReasonResponseFreshnessCapped
+
+	// The Date header of the response was further in the future than the cache's clock skew threshold.
+	//
+	// This reason is informational, the freshness lifetime is still relative to the Date header.
+	ReasonResponseClockSkew
+
+	// The response included Pragma: no-cache and no Cache-Control header, and the cache was configured
+	// to treat it as Cache-Control: no-cache: http://tools.ietf.org/html/rfc7234#section-5.4
+	//
+	// This reason is informational, the response may be stored but must be revalidated before each use.
+	ReasonResponsePragmaNoCache
+
+	// The response included both max-age and an Expires header, and the freshness lifetimes
+	// they imply differed by more than the cache's threshold, which often indicates a
+	// misconfigured origin.
+	//
+	// This reason is informational, max-age takes precedence: http://tools.ietf.org/html/rfc7234#section-5.3
+	ReasonResponseMaxAgeExpiresDisagree
+
+	// The response included an extension directive, like stale-while-revalidate, which is not in
+	// the cache's known extensions, so it was ignored: http://tools.ietf.org/html/rfc7234#section-5.2.3
+	//
+	// This reason is informational, the response may still be cached.
+	ReasonResponseUnknownDirective
+
+	// The response included a directive with an invalid value, like `max-age=abc`, which was
+	// dropped because it was parsed leniently, see ResponseCacheDirectives.InvalidDirectives.
+	//
+	// This reason is informational, the response may still be cached, eg, until its Expires header.
+	// It is only emitted with Object.EmitInfoReasons.
+	ReasonResponseInvalidDirectiveIgnored
+
+	// The response's Age header was already larger than its freshness lifetime when it was received,
+	// so it must be revalidated before it is used: http://tools.ietf.org/html/rfc7234#section-4.2.3
+	//
+	// This reason is informational, the response may still be stored. It is only emitted
+	// with Object.EmitInfoReasons.
+	ReasonResponseAlreadyStaleOnReceipt
 )
 
+// Returns every Reason, in the order they are declared.
+func AllReasons() []Reason {
+	return []Reason{
+		ReasonRequestMethodPOST,
+		ReasonRequestMethodPUT,
+		ReasonRequestMethodDELETE,
+		ReasonRequestMethodCONNECT,
+		ReasonRequestMethodOPTIONS,
+		ReasonRequestMethodTRACE,
+		ReasonRequestMethodUnknown,
+		ReasonRequestNoStore,
+		ReasonRequestAuthorizationHeader,
+		ReasonResponseNoStore,
+		ReasonResponsePrivate,
+		ReasonResponseUncachableByDefault,
+		ReasonRequestOnlyIfCached,
+		ReasonResponseNotModified,
+		ReasonRequestContradictoryDirectives,
+		ReasonRequestUncachableHeader,
+		ReasonResponseInformational,
+		ReasonResponseBodyTooLarge,
+		ReasonResponsePOSTContentLocationMismatch,
+		ReasonResponseCompressedWithoutVary,
+		ReasonResponseNoValidator,
+		ReasonResponseTransformConflict,
+		ReasonResponseVaryCookie,
+		ReasonResponseRangeUnsupported,
+		ReasonResponseMultipartRange,
+		ReasonResponseLengthMismatch,
+	}
+}
+
+// Returns every informational Reason, in the order they are declared.
+func AllInfoReasons() []Reason {
+	return []Reason{
+		ReasonInfoPOSTCachableWithFreshness,
+		ReasonResponseHeuristicOnly,
+		ReasonResponseFreshnessCapped,
+		ReasonResponseClockSkew,
+		ReasonResponsePragmaNoCache,
+		ReasonResponseMaxAgeExpiresDisagree,
+		ReasonResponseUnknownDirective,
+		ReasonResponseInvalidDirectiveIgnored,
+		ReasonResponseAlreadyStaleOnReceipt,
+	}
+}
+
+// Returns true for informational reasons, which explain how a response was cached,
+// rather than preventing it from being cached.
+func (r Reason) IsInfo() bool {
+	return r >= reasonInfoBase
+}
+
 func (r Reason) String() string {
 	switch r {
+	case ReasonNone:
+		return "ReasonNone"
 	case ReasonRequestMethodPOST:
 		return "ReasonRequestMethodPOST"
 	case ReasonRequestMethodPUT:
@@ -89,7 +278,151 @@ func (r Reason) String() string {
 		return "ReasonResponsePrivate"
 	case ReasonResponseUncachableByDefault:
 		return "ReasonResponseUncachableByDefault"
+	case ReasonRequestOnlyIfCached:
+		return "ReasonRequestOnlyIfCached"
+	case ReasonResponseFreshnessCapped:
+		return "ReasonResponseFreshnessCapped"
+	case ReasonResponseClockSkew:
+		return "ReasonResponseClockSkew"
+	case ReasonResponseNotModified:
+		return "ReasonResponseNotModified"
+	case ReasonRequestContradictoryDirectives:
+		return "ReasonRequestContradictoryDirectives"
+	case ReasonRequestUncachableHeader:
+		return "ReasonRequestUncachableHeader"
+	case ReasonResponseInformational:
+		return "ReasonResponseInformational"
+	case ReasonResponsePragmaNoCache:
+		return "ReasonResponsePragmaNoCache"
+	case ReasonResponseBodyTooLarge:
+		return "ReasonResponseBodyTooLarge"
+	case ReasonResponsePOSTContentLocationMismatch:
+		return "ReasonResponsePOSTContentLocationMismatch"
+	case ReasonResponseCompressedWithoutVary:
+		return "ReasonResponseCompressedWithoutVary"
+	case ReasonResponseNoValidator:
+		return "ReasonResponseNoValidator"
+	case ReasonResponseTransformConflict:
+		return "ReasonResponseTransformConflict"
+	case ReasonResponseMaxAgeExpiresDisagree:
+		return "ReasonResponseMaxAgeExpiresDisagree"
+	case ReasonResponseAlreadyStaleOnReceipt:
+		return "ReasonResponseAlreadyStaleOnReceipt"
+	case ReasonResponseVaryCookie:
+		return "ReasonResponseVaryCookie"
+	case ReasonResponseRangeUnsupported:
+		return "ReasonResponseRangeUnsupported"
+	case ReasonResponseMultipartRange:
+		return "ReasonResponseMultipartRange"
+	case ReasonResponseUnknownDirective:
+		return "ReasonResponseUnknownDirective"
+	case ReasonResponseLengthMismatch:
+		return "ReasonResponseLengthMismatch"
+	case ReasonResponseInvalidDirectiveIgnored:
+		return "ReasonResponseInvalidDirectiveIgnored"
+	case ReasonInfoPOSTCachableWithFreshness:
+		return "ReasonInfoPOSTCachableWithFreshness"
+	case ReasonResponseHeuristicOnly:
+		return "ReasonResponseHeuristicOnly"
 	}
 
 	panic(r)
 }
+
+// Whether a Reason was caused by the request, ie, the client, or by the response, ie,
+// the origin server, to help triage why a response wasn't cached.
+type ReasonSource int
+
+const (
+	// The Reason was caused by the request, eg, its method or directives.
+	SourceRequest ReasonSource = iota
+
+	// The Reason was caused by the response's headers or directives.
+	SourceResponse
+
+	// The Reason was caused by the response's status code.
+	SourceStatus
+)
+
+func (s ReasonSource) String() string {
+	switch s {
+	case SourceRequest:
+		return "SourceRequest"
+	case SourceResponse:
+		return "SourceResponse"
+	case SourceStatus:
+		return "SourceStatus"
+	}
+
+	panic(s)
+}
+
+// Returns whether this Reason was caused by the request, or by the response's
+// headers or status code.
+func (r Reason) Source() ReasonSource {
+	switch r {
+	case ReasonRequestMethodPOST,
+		ReasonRequestMethodPUT,
+		ReasonRequestMethodDELETE,
+		ReasonRequestMethodCONNECT,
+		ReasonRequestMethodOPTIONS,
+		ReasonRequestMethodTRACE,
+		ReasonRequestMethodUnknown,
+		ReasonRequestNoStore,
+		ReasonRequestAuthorizationHeader,
+		ReasonRequestOnlyIfCached,
+		ReasonRequestContradictoryDirectives,
+		ReasonRequestUncachableHeader,
+		ReasonInfoPOSTCachableWithFreshness:
+		return SourceRequest
+	case ReasonResponseUncachableByDefault,
+		ReasonResponseNotModified,
+		ReasonResponseInformational,
+		ReasonResponseTransformConflict,
+		ReasonResponseRangeUnsupported,
+		ReasonResponseMultipartRange,
+		ReasonResponseHeuristicOnly:
+		return SourceStatus
+	}
+
+	return SourceResponse
+}
+
+// Returns the HTTP status code a cache should respond with because of this
+// Reason, if the Reason implies one.
+func (r Reason) SuggestedStatus() (int, bool) {
+	switch r {
+	case ReasonRequestOnlyIfCached:
+		return http.StatusGatewayTimeout, true
+	case ReasonRequestContradictoryDirectives:
+		return http.StatusBadRequest, true
+	}
+
+	return 0, false
+}
+
+// Returns the reasons which apply to a private cache, or to a shared cache, by removing
+// the reasons which only apply to shared caches, like ReasonResponsePrivate, for a private cache.
+//
+// Filtering can only remove reasons, it can't add the reasons which only apply to a private
+// cache: eg, a 302 Found response whose only freshness is s-maxage is ReasonResponseUncachableByDefault
+// for a private cache, but cachable for a shared one. So a private cache must evaluate the
+// response itself, with Object.CacheIsPrivate set, rather than filter a shared evaluation.
+func FilterReasonsForCache(reasons []Reason, privateCache bool) []Reason {
+	filtered := make([]Reason, 0, len(reasons))
+	for _, r := range reasons {
+		if privateCache && r.sharedCacheOnly() {
+			continue
+		}
+		filtered = append(filtered, r)
+	}
+	return filtered
+}
+
+func (r Reason) sharedCacheOnly() bool {
+	switch r {
+	case ReasonResponsePrivate, ReasonResponseCompressedWithoutVary, ReasonResponseVaryCookie:
+		return true
+	}
+	return false
+}
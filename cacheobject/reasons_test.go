@@ -0,0 +1,135 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cacheobject
+
+import (
+	"github.com/stretchr/testify/require"
+
+	"net/http"
+	"testing"
+)
+
+func TestSuggestedStatusOnlyIfCached(t *testing.T) {
+	status, ok := ReasonRequestOnlyIfCached.SuggestedStatus()
+	require.True(t, ok)
+	require.Equal(t, http.StatusGatewayTimeout, status)
+}
+
+func TestSuggestedStatusContradictoryDirectives(t *testing.T) {
+	status, ok := ReasonRequestContradictoryDirectives.SuggestedStatus()
+	require.True(t, ok)
+	require.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestSuggestedStatusNone(t *testing.T) {
+	for _, r := range []Reason{
+		ReasonRequestMethodPOST,
+		ReasonRequestNoStore,
+		ReasonResponsePrivate,
+		ReasonResponseUncachableByDefault,
+	} {
+		status, ok := r.SuggestedStatus()
+		require.False(t, ok, "reason should not suggest a status: %s", r)
+		require.Equal(t, 0, status)
+	}
+}
+
+func TestAllReasons(t *testing.T) {
+	all := AllReasons()
+
+	seen := map[string]bool{}
+	for i, r := range all {
+		require.Equal(t, Reason(i), r, "reasons should be in declaration order")
+		require.False(t, seen[r.String()], "reason string should be unique: %s", r)
+		seen[r.String()] = true
+	}
+
+	// every declared reason is covered
+	require.Panics(t, func() {
+		_ = Reason(len(all)).String()
+	})
+}
+
+func TestFilterReasonsForCache(t *testing.T) {
+	reasons := []Reason{ReasonResponsePrivate, ReasonRequestNoStore}
+
+	require.Equal(t, []Reason{ReasonRequestNoStore}, FilterReasonsForCache(reasons, true))
+	require.Equal(t, reasons, FilterReasonsForCache(reasons, false))
+	require.Equal(t, []Reason{}, FilterReasonsForCache(nil, true))
+}
+
+func TestAllInfoReasons(t *testing.T) {
+	for _, r := range AllInfoReasons() {
+		require.True(t, r.IsInfo(), "reason should be informational: %s", r)
+		require.NotPanics(t, func() {
+			_ = r.String()
+		})
+	}
+
+	for _, r := range AllReasons() {
+		require.False(t, r.IsInfo(), "reason should not be informational: %s", r)
+	}
+}
+
+func TestReasonSource(t *testing.T) {
+	expected := map[Reason]ReasonSource{
+		ReasonRequestMethodPOST:                   SourceRequest,
+		ReasonRequestMethodPUT:                    SourceRequest,
+		ReasonRequestMethodDELETE:                 SourceRequest,
+		ReasonRequestMethodCONNECT:                SourceRequest,
+		ReasonRequestMethodOPTIONS:                SourceRequest,
+		ReasonRequestMethodTRACE:                  SourceRequest,
+		ReasonRequestMethodUnknown:                SourceRequest,
+		ReasonRequestNoStore:                      SourceRequest,
+		ReasonRequestAuthorizationHeader:          SourceRequest,
+		ReasonResponseNoStore:                     SourceResponse,
+		ReasonResponsePrivate:                     SourceResponse,
+		ReasonResponseUncachableByDefault:         SourceStatus,
+		ReasonRequestOnlyIfCached:                 SourceRequest,
+		ReasonResponseFreshnessCapped:             SourceResponse,
+		ReasonResponseClockSkew:                   SourceResponse,
+		ReasonResponseNotModified:                 SourceStatus,
+		ReasonRequestContradictoryDirectives:      SourceRequest,
+		ReasonRequestUncachableHeader:             SourceRequest,
+		ReasonResponseInformational:               SourceStatus,
+		ReasonResponsePragmaNoCache:               SourceResponse,
+		ReasonResponseBodyTooLarge:                SourceResponse,
+		ReasonResponsePOSTContentLocationMismatch: SourceResponse,
+		ReasonResponseCompressedWithoutVary:       SourceResponse,
+		ReasonResponseNoValidator:                 SourceResponse,
+		ReasonResponseTransformConflict:           SourceStatus,
+		ReasonResponseMaxAgeExpiresDisagree:       SourceResponse,
+		ReasonResponseAlreadyStaleOnReceipt:       SourceResponse,
+		ReasonResponseVaryCookie:                  SourceResponse,
+		ReasonResponseRangeUnsupported:            SourceStatus,
+		ReasonResponseMultipartRange:              SourceStatus,
+		ReasonResponseUnknownDirective:            SourceResponse,
+		ReasonResponseLengthMismatch:              SourceResponse,
+		ReasonResponseInvalidDirectiveIgnored:     SourceResponse,
+		ReasonInfoPOSTCachableWithFreshness:       SourceRequest,
+		ReasonResponseHeuristicOnly:               SourceStatus,
+	}
+
+	all := append(AllReasons(), AllInfoReasons()...)
+	require.Len(t, expected, len(all))
+	for _, r := range all {
+		source, ok := expected[r]
+		require.True(t, ok, "missing reason: %s", r)
+		require.Equal(t, source, r.Source(), r.String())
+	}
+}
@@ -0,0 +1,226 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cacheobject
+
+import (
+	"math"
+	"net/http"
+	"time"
+)
+
+// LOW LEVEL API: Check if a stored response must be validated on the origin server
+// before it is used to satisfy a new request: http://tools.ietf.org/html/rfc7234#section-4
+//
+// obj.ReqDirectives are the directives of the new request, obj.NowUTC is the time
+// of reuse, or the current time when zero, and expiresAt is the expiration time calculated by ExpirationObject
+// when the response was stored.
+//
+// A response with must-revalidate but no explicit freshness is not revalidated on
+// every use: like any other response, it is fresh for its heuristic freshness
+// lifetime, and must-revalidate only prevents serving it once it becomes stale,
+// see CanServeStale. Without a Last-Modified header there is no heuristic freshness,
+// so it must be revalidated immediately.
+func MustRevalidateBeforeUse(obj *Object, expiresAt time.Time) bool {
+	now := obj.now()
+
+	// an unqualified no-cache applies to the whole response: http://tools.ietf.org/html/rfc7234#section-5.2.2.2
+	if respNoCache(obj) {
+		return true
+	}
+
+	if obj.ReqDirectives != nil && obj.ReqDirectives.NoCache {
+		return true
+	}
+
+	if !now.Before(expiresAt) {
+		// stale
+		return true
+	}
+
+	if obj.ReqDirectives == nil {
+		return false
+	}
+
+	/**
+	 * A fresh immutable response is not revalidated because a client wants
+	 * to reload, eg, by sending max-age=0: https://tools.ietf.org/html/rfc8246#section-2
+	 * Once stale, it was revalidated like any other response above.
+	 */
+	if obj.ReqDirectives.MaxAge != -1 && !obj.RespDirectives.Immutable {
+		if currentAge(obj, now) > time.Second*time.Duration(obj.ReqDirectives.MaxAge) {
+			return true
+		}
+	}
+
+	if obj.ReqDirectives.MinFresh != -1 {
+		if expiresAt.Sub(now) < time.Second*time.Duration(obj.ReqDirectives.MinFresh) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LOW LEVEL API: Calculate how much longer a stored response may be used to satisfy a
+// request, given respRemaining, the time until the response becomes stale (negative
+// when it already is), and age, its current age: http://tools.ietf.org/html/rfc7234#section-5.2.1
+//
+// The request's max-stale extends the response's remaining freshness, an unbounded
+// max-stale removes it as a constraint altogether. The request's max-age caps the
+// result at max-age minus age, and min-fresh is subtracted from it. A zero or negative
+// result means the response can't be used without validating it.
+func EffectiveRemainingFreshness(respRemaining time.Duration, age time.Duration, reqDir *RequestCacheDirectives) time.Duration {
+	if reqDir == nil {
+		return respRemaining
+	}
+
+	remaining := respRemaining
+	if reqDir.MaxStaleSet {
+		remaining = time.Duration(math.MaxInt64)
+	} else if reqDir.MaxStale != -1 {
+		remaining += time.Second * time.Duration(reqDir.MaxStale)
+	}
+
+	if reqDir.MaxAge != -1 {
+		maxAgeRemaining := time.Second*time.Duration(reqDir.MaxAge) - age
+		if maxAgeRemaining < remaining {
+			remaining = maxAgeRemaining
+		}
+	}
+
+	if reqDir.MinFresh != -1 {
+		remaining -= time.Second * time.Duration(reqDir.MinFresh)
+	}
+
+	return remaining
+}
+
+// LOW LEVEL API: Check if a response's directives allow serving it once it has become stale
+// at all, regardless of how stale it is. It never is when the response contains must-revalidate
+// or an unqualified no-cache, or for shared caches, proxy-revalidate or s-maxage:
+// http://tools.ietf.org/html/rfc7234#section-4.2.4
+//
+// CanServeStale and CanServeStaleOnError both apply these rules.
+func MayServeStale(respDir *ResponseCacheDirectives, privateCache bool) bool {
+	if respDir == nil {
+		return false
+	}
+
+	if respDir.MustRevalidate {
+		return false
+	}
+
+	if !privateCache && (respDir.ProxyRevalidate || respDir.SMaxAge != -1) {
+		return false
+	}
+
+	return !respDir.NoCachePresent || len(respDir.NoCache) != 0
+}
+
+// LOW LEVEL API: Check if a stored response which has become stale may be used to
+// satisfy a new request without validating it on the origin server, because of the
+// response's stale-while-revalidate directive or the request's max-stale directive.
+//
+// Serving stale responses is never allowed when the response contains must-revalidate,
+// or for shared caches, proxy-revalidate or s-maxage: http://tools.ietf.org/html/rfc7234#section-4.2.4
+//
+// Returns false if the response is still fresh, see MustRevalidateBeforeUse.
+func CanServeStale(obj *Object, expiresAt time.Time) bool {
+	now := obj.now()
+	if now.Before(expiresAt) {
+		return false
+	}
+
+	if !MayServeStale(obj.RespDirectives, obj.CacheIsPrivate) || respPragmaNoCache(obj) {
+		return false
+	}
+
+	staleness := now.Sub(expiresAt)
+
+	// https://tools.ietf.org/html/rfc5861#section-3
+	if obj.RespDirectives.StaleWhileRevalidate != -1 &&
+		staleness <= time.Second*time.Duration(obj.RespDirectives.StaleWhileRevalidate) {
+		return true
+	}
+
+	if obj.ReqDirectives != nil {
+		if obj.ReqDirectives.MaxStaleSet {
+			return true
+		}
+
+		if obj.ReqDirectives.MaxStale != -1 &&
+			staleness <= time.Second*time.Duration(obj.ReqDirectives.MaxStale) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LOW LEVEL API: Check if a stored response may be used to satisfy a new request, and
+// if so, whether it must be validated on the origin server first.
+//
+// obj describes the stored response and the new request, and storedReqHeaders are the
+// headers of the request the response was stored for. When the new request doesn't match
+// the stored variant, or the response must be validated before use, a conditional request
+// is only possible if the response has a validator (ETag or Last-Modified). Without one,
+// the stored response can't be reused at all.
+func CanReuse(obj *Object, storedReqHeaders http.Header, expiresAt time.Time) (reuse bool, revalidate bool) {
+	validator := hasValidator(obj)
+
+	if !VaryMatches(obj.RespHeaders, storedReqHeaders, obj.ReqHeaders) {
+		return validator, validator
+	}
+
+	if !MustRevalidateBeforeUse(obj, expiresAt) {
+		return true, false
+	}
+
+	reqNoCache := obj.ReqDirectives != nil && obj.ReqDirectives.NoCache
+	if !reqNoCache && CanServeStale(obj, expiresAt) {
+		return true, false
+	}
+
+	return validator, validator
+}
+
+// LOW LEVEL API: Check if a stale response may be served because the origin server failed
+// while it was being validated, due to the stale-if-error directive: https://tools.ietf.org/html/rfc5861#section-4
+//
+// The origin failed if it returned a 5xx status code, or originErr is set, eg, because the
+// request timed out or its deadline was exceeded. age is the current age of the response, and
+// freshnessLifetime how long it was fresh for.
+//
+// Like CanServeStale, serving stale responses is never allowed when the response contains
+// must-revalidate, or for shared caches, proxy-revalidate or s-maxage: http://tools.ietf.org/html/rfc7234#section-4.2.4
+func CanServeStaleOnError(respDir *ResponseCacheDirectives, privateCache bool, age, freshnessLifetime time.Duration, originStatus int, originErr error) bool {
+	if respDir == nil || respDir.StaleIfError == -1 {
+		return false
+	}
+
+	if !MayServeStale(respDir, privateCache) {
+		return false
+	}
+
+	if originErr == nil && (originStatus < 500 || originStatus > 599) {
+		return false
+	}
+
+	staleness := age - freshnessLifetime
+	return staleness <= time.Second*time.Duration(respDir.StaleIfError)
+}
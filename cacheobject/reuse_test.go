@@ -0,0 +1,426 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cacheobject
+
+import (
+	"github.com/stretchr/testify/require"
+
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMustRevalidateFresh(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespDateHeader = now.Add(time.Second * -10)
+
+	require.False(t, MustRevalidateBeforeUse(&obj, now.Add(time.Minute)))
+}
+
+func TestMustRevalidateStale(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespDateHeader = now.Add(time.Second * -10)
+
+	require.True(t, MustRevalidateBeforeUse(&obj, now.Add(time.Second*-1)))
+}
+
+func TestMustRevalidateReqMaxAgeZero(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespDateHeader = now.Add(time.Second * -10)
+	obj.ReqDirectives.MaxAge = DeltaSeconds(0)
+
+	require.True(t, MustRevalidateBeforeUse(&obj, now.Add(time.Minute)))
+}
+
+func TestMustRevalidateImmutableReqMaxAgeZero(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespDateHeader = now.Add(time.Second * -10)
+	obj.RespDirectives.Immutable = true
+	obj.ReqDirectives.MaxAge = DeltaSeconds(0)
+
+	require.False(t, MustRevalidateBeforeUse(&obj, now.Add(time.Minute)))
+}
+
+func TestMustRevalidateReqNoCache(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespDirectives.Immutable = true
+	obj.ReqDirectives.NoCache = true
+
+	require.True(t, MustRevalidateBeforeUse(&obj, now.Add(time.Minute)))
+}
+
+func TestMustRevalidateRespNoCache(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespDirectives.NoCachePresent = true
+
+	require.True(t, MustRevalidateBeforeUse(&obj, now.Add(time.Minute)))
+}
+
+func TestMustRevalidateReqMinFresh(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.ReqDirectives.MinFresh = DeltaSeconds(120)
+
+	require.True(t, MustRevalidateBeforeUse(&obj, now.Add(time.Minute)))
+	require.False(t, MustRevalidateBeforeUse(&obj, now.Add(time.Minute*5)))
+}
+
+func TestCanServeStaleWhileRevalidate(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespDirectives.StaleWhileRevalidate = DeltaSeconds(60)
+
+	require.True(t, CanServeStale(&obj, now.Add(time.Second*-30)))
+	require.False(t, CanServeStale(&obj, now.Add(time.Second*-90)))
+	// fresh
+	require.False(t, CanServeStale(&obj, now.Add(time.Second*30)))
+}
+
+func TestCanServeStaleMustRevalidate(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	RespDirectives, err := ParseResponseCacheControl("stale-while-revalidate=60, must-revalidate")
+	require.NoError(t, err)
+	obj.RespDirectives = RespDirectives
+
+	require.False(t, CanServeStale(&obj, now.Add(time.Second*-30)))
+
+	obj.CacheIsPrivate = true
+	require.False(t, CanServeStale(&obj, now.Add(time.Second*-30)))
+}
+
+func TestCanServeStaleProxyRevalidate(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	RespDirectives, err := ParseResponseCacheControl("stale-while-revalidate=60, proxy-revalidate")
+	require.NoError(t, err)
+	obj.RespDirectives = RespDirectives
+
+	require.False(t, CanServeStale(&obj, now.Add(time.Second*-30)))
+
+	// proxy-revalidate does not apply to private caches
+	obj.CacheIsPrivate = true
+	require.True(t, CanServeStale(&obj, now.Add(time.Second*-30)))
+}
+
+func TestCanServeStaleReqMaxStale(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.ReqDirectives.MaxStale = DeltaSeconds(120)
+
+	require.True(t, CanServeStale(&obj, now.Add(time.Second*-90)))
+	require.False(t, CanServeStale(&obj, now.Add(time.Second*-150)))
+
+	obj.ReqDirectives.MaxStaleSet = true
+	require.True(t, CanServeStale(&obj, now.Add(time.Hour*-1)))
+
+	obj.RespDirectives.MustRevalidate = true
+	require.False(t, CanServeStale(&obj, now.Add(time.Hour*-1)))
+}
+
+func TestCanReuseVaryMismatchWithValidator(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespHeaders.Set("Vary", "Accept-Language")
+	obj.RespHeaders.Set("ETag", `"abc"`)
+	obj.ReqHeaders.Set("Accept-Language", "de")
+	stored := http.Header{"Accept-Language": {"en"}}
+
+	reuse, revalidate := CanReuse(&obj, stored, now.Add(time.Minute))
+	require.True(t, reuse)
+	require.True(t, revalidate)
+}
+
+func TestCanReuseVaryMismatchNoValidator(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespHeaders.Set("Vary", "Accept-Language")
+	obj.ReqHeaders.Set("Accept-Language", "de")
+	stored := http.Header{"Accept-Language": {"en"}}
+
+	// the stored variant can't be revalidated, this is a miss.
+	reuse, revalidate := CanReuse(&obj, stored, now.Add(time.Minute))
+	require.False(t, reuse)
+	require.False(t, revalidate)
+}
+
+func TestCanReuseVaryMatch(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespHeaders.Set("Vary", "Accept-Language")
+	obj.RespHeaders.Set("ETag", `"abc"`)
+	obj.ReqHeaders.Set("Accept-Language", "en")
+	stored := http.Header{"Accept-Language": {"en"}}
+
+	reuse, revalidate := CanReuse(&obj, stored, now.Add(time.Minute))
+	require.True(t, reuse)
+	require.False(t, revalidate)
+}
+
+func TestCanReuseStale(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespHeaders.Set("ETag", `"abc"`)
+
+	reuse, revalidate := CanReuse(&obj, http.Header{}, now.Add(time.Minute*-1))
+	require.True(t, reuse)
+	require.True(t, revalidate)
+
+	obj.RespDirectives.StaleWhileRevalidate = DeltaSeconds(120)
+	reuse, revalidate = CanReuse(&obj, http.Header{}, now.Add(time.Minute*-1))
+	require.True(t, reuse)
+	require.False(t, revalidate)
+}
+
+func TestCanServeStaleOnErrorTimeout(t *testing.T) {
+	respDir, err := ParseResponseCacheControl("max-age=60, stale-if-error=300")
+	require.NoError(t, err)
+
+	require.True(t, CanServeStaleOnError(respDir, false, time.Second*120, time.Second*60, 0, context.DeadlineExceeded))
+	require.False(t, CanServeStaleOnError(respDir, false, time.Second*600, time.Second*60, 0, context.DeadlineExceeded))
+}
+
+func TestCanServeStaleOnError5xx(t *testing.T) {
+	respDir, err := ParseResponseCacheControl("max-age=60, stale-if-error=300")
+	require.NoError(t, err)
+
+	require.True(t, CanServeStaleOnError(respDir, false, time.Second*120, time.Second*60, 503, nil))
+	require.True(t, CanServeStaleOnError(respDir, false, time.Second*120, time.Second*60, 500, nil))
+	require.False(t, CanServeStaleOnError(respDir, false, time.Second*120, time.Second*60, 404, nil))
+}
+
+func TestCanServeStaleOnErrorSuccess(t *testing.T) {
+	respDir, err := ParseResponseCacheControl("max-age=60, stale-if-error=300")
+	require.NoError(t, err)
+
+	require.False(t, CanServeStaleOnError(respDir, false, time.Second*120, time.Second*60, 200, nil))
+}
+
+func TestCanServeStaleOnErrorNoDirective(t *testing.T) {
+	respDir, err := ParseResponseCacheControl("max-age=60")
+	require.NoError(t, err)
+
+	require.False(t, CanServeStaleOnError(respDir, false, time.Second*120, time.Second*60, 503, nil))
+}
+
+func TestEffectiveRemainingFreshnessNoRequestDirectives(t *testing.T) {
+	reqDir, err := ParseRequestCacheControl("")
+	require.NoError(t, err)
+
+	require.Equal(t, time.Minute, EffectiveRemainingFreshness(time.Minute, time.Second*30, reqDir))
+	require.Equal(t, time.Minute, EffectiveRemainingFreshness(time.Minute, time.Second*30, nil))
+}
+
+func TestEffectiveRemainingFreshnessReqMaxAge(t *testing.T) {
+	reqDir, err := ParseRequestCacheControl("max-age=40")
+	require.NoError(t, err)
+
+	require.Equal(t, time.Second*10, EffectiveRemainingFreshness(time.Minute, time.Second*30, reqDir))
+	require.Equal(t, time.Second*5, EffectiveRemainingFreshness(time.Second*5, time.Second*30, reqDir))
+}
+
+func TestEffectiveRemainingFreshnessReqMinFresh(t *testing.T) {
+	reqDir, err := ParseRequestCacheControl("min-fresh=20")
+	require.NoError(t, err)
+
+	require.Equal(t, time.Second*40, EffectiveRemainingFreshness(time.Minute, time.Second*30, reqDir))
+	require.Equal(t, time.Second*-10, EffectiveRemainingFreshness(time.Second*10, time.Second*30, reqDir))
+}
+
+func TestEffectiveRemainingFreshnessReqMaxStale(t *testing.T) {
+	reqDir, err := ParseRequestCacheControl("max-stale=60")
+	require.NoError(t, err)
+
+	require.Equal(t, time.Second*30, EffectiveRemainingFreshness(time.Second*-30, time.Second*90, reqDir))
+	require.Equal(t, time.Second*-30, EffectiveRemainingFreshness(time.Second*-90, time.Second*150, reqDir))
+}
+
+func TestEffectiveRemainingFreshnessReqMaxStaleUnbounded(t *testing.T) {
+	reqDir, err := ParseRequestCacheControl("max-stale, max-age=120")
+	require.NoError(t, err)
+
+	require.Equal(t, time.Second*30, EffectiveRemainingFreshness(time.Hour*-1, time.Second*90, reqDir))
+}
+
+func TestMustRevalidateOnlyRecentLastModified(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespDirectives.MustRevalidate = true
+	obj.RespLastModifiedHeader = now.Add(time.Minute * -10)
+
+	rv := ObjectResults{}
+	ExpirationObject(&obj, &rv)
+	require.WithinDuration(t, now.Add(time.Minute), rv.OutExpirationTime, time.Second)
+
+	require.False(t, MustRevalidateBeforeUse(&obj, rv.OutExpirationTime))
+
+	obj.NowUTC = now.Add(time.Minute * 2)
+	require.True(t, MustRevalidateBeforeUse(&obj, rv.OutExpirationTime))
+	require.False(t, CanServeStale(&obj, rv.OutExpirationTime))
+}
+
+func TestMustRevalidateOnlyOldLastModified(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespDirectives.MustRevalidate = true
+	obj.RespLastModifiedHeader = now.Add(time.Hour * -24 * 365)
+
+	rv := ObjectResults{}
+	ExpirationObject(&obj, &rv)
+	require.WithinDuration(t, now.Add(twentyFourHours), rv.OutExpirationTime, time.Second)
+
+	obj.NowUTC = now.Add(time.Hour * 23)
+	require.False(t, MustRevalidateBeforeUse(&obj, rv.OutExpirationTime))
+}
+
+func TestMustRevalidateOnlyNoLastModified(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespDirectives.MustRevalidate = true
+
+	rv := ObjectResults{}
+	ExpirationObject(&obj, &rv)
+	require.True(t, rv.OutExpirationTime.IsZero())
+	require.True(t, MustRevalidateBeforeUse(&obj, rv.OutExpirationTime))
+}
+
+func TestSMaxAgeZeroSharedCache(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespDirectives.SMaxAge = DeltaSeconds(0)
+	obj.RespDirectives.MaxAge = DeltaSeconds(300)
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	ExpirationObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 0)
+	require.Equal(t, now, rv.OutExpirationTime)
+
+	require.True(t, MustRevalidateBeforeUse(&obj, rv.OutExpirationTime))
+	require.False(t, CanServeStale(&obj, rv.OutExpirationTime))
+}
+
+func TestSMaxAgeZeroPrivateCache(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.CacheIsPrivate = true
+	obj.RespDirectives.SMaxAge = DeltaSeconds(0)
+	obj.RespDirectives.MaxAge = DeltaSeconds(300)
+
+	rv := ObjectResults{}
+	CachableObject(&obj, &rv)
+	ExpirationObject(&obj, &rv)
+	require.NoError(t, rv.OutErr)
+	require.Len(t, rv.OutReasons, 0)
+	require.Equal(t, now.Add(time.Second*300), rv.OutExpirationTime)
+
+	require.False(t, MustRevalidateBeforeUse(&obj, rv.OutExpirationTime))
+}
+
+func TestMustRevalidateImmutableStale(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespDirectives.Immutable = true
+	obj.RespHeaders.Set("ETag", `"v1"`)
+
+	require.True(t, MustRevalidateBeforeUse(&obj, now.Add(time.Second*-1)))
+
+	reuse, revalidate := CanReuse(&obj, http.Header{}, now.Add(time.Second*-1))
+	require.True(t, reuse)
+	require.True(t, revalidate)
+}
+
+func TestMustRevalidateImmutableFresh(t *testing.T) {
+	now := time.Now().UTC()
+
+	obj := fill(t, now)
+	obj.RespDirectives.Immutable = true
+	obj.RespHeaders.Set("ETag", `"v1"`)
+
+	require.False(t, MustRevalidateBeforeUse(&obj, now.Add(time.Minute)))
+
+	reuse, revalidate := CanReuse(&obj, http.Header{}, now.Add(time.Minute))
+	require.True(t, reuse)
+	require.False(t, revalidate)
+}
+
+func TestCanServeStaleOnErrorMustRevalidate(t *testing.T) {
+	respDir, err := ParseResponseCacheControl("max-age=60, stale-if-error=300, must-revalidate")
+	require.NoError(t, err)
+
+	require.False(t, CanServeStaleOnError(respDir, false, time.Second*120, time.Second*60, 503, nil))
+	require.False(t, CanServeStaleOnError(respDir, true, time.Second*120, time.Second*60, 503, nil))
+}
+
+func TestCanServeStaleOnErrorSharedCache(t *testing.T) {
+	respDir, err := ParseResponseCacheControl("s-maxage=60, stale-if-error=300")
+	require.NoError(t, err)
+
+	require.False(t, CanServeStaleOnError(respDir, false, time.Second*120, time.Second*60, 503, nil))
+	require.True(t, CanServeStaleOnError(respDir, true, time.Second*120, time.Second*60, 503, nil))
+}
+
+func TestCanServeStaleOnErrorNilDirectives(t *testing.T) {
+	require.False(t, CanServeStaleOnError(nil, false, time.Second*120, time.Second*60, 503, nil))
+}
+
+func TestMayServeStale(t *testing.T) {
+	respDir, err := ParseResponseCacheControl("max-age=60, proxy-revalidate")
+	require.NoError(t, err)
+	require.False(t, MayServeStale(respDir, false))
+	require.True(t, MayServeStale(respDir, true))
+
+	respDir, err = ParseResponseCacheControl("max-age=60, must-revalidate")
+	require.NoError(t, err)
+	require.False(t, MayServeStale(respDir, true))
+
+	respDir, err = ParseResponseCacheControl(`no-cache="Set-Cookie", max-age=60`)
+	require.NoError(t, err)
+	require.True(t, MayServeStale(respDir, false))
+
+	require.False(t, MayServeStale(nil, true))
+}
@@ -0,0 +1,136 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cacheobject
+
+import (
+	"strings"
+)
+
+// LOW LEVEL API: Representation of directives in a `Surrogate-Control` header,
+// from the Edge Architecture Specification: https://www.w3.org/TR/edge-arch/
+//
+// Surrogate-Control is targeted at surrogates (eg, a CDN), and SHOULD be removed
+// before a response is forwarded downstream.
+type SurrogateControlDirectives struct {
+	// max-age(delta seconds)
+	//
+	// The freshness lifetime of the response for surrogates. The optional
+	// `+delta` form of the directive is accepted, but only the freshness
+	// lifetime is used.
+	MaxAge DeltaSeconds
+
+	// no-store(bool)
+	//
+	// The response MUST NOT be stored by surrogates.
+	NoStore bool
+
+	// no-store-remote(bool)
+	//
+	// Same as no-store, but only applies to surrogates which are not near
+	// the origin server, such as a CDN.
+	NoStoreRemote bool
+
+	// Extensions, including `content`, which are not interpreted.
+	Extensions []string
+}
+
+// LOW LEVEL API: Parses a Surrogate-Control Header from a Response into a set of directives.
+//
+// Like ParseResponseCacheControl, values containing any CR or LF are rejected with ErrObsoleteLineFolding.
+func ParseSurrogateControl(value string) (*SurrogateControlDirectives, error) {
+	if strings.ContainsAny(value, "\r\n") {
+		return nil, ErrObsoleteLineFolding
+	}
+
+	sc := &SurrogateControlDirectives{
+		MaxAge: -1,
+	}
+
+	err := parse(value, sc)
+	if err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
+func (sc *SurrogateControlDirectives) addToken(token string) error {
+	var err error = nil
+
+	switch token {
+	case "max-age":
+		err = ErrMaxAgeDeltaSeconds
+	case "no-store":
+		sc.NoStore = true
+	case "no-store-remote":
+		sc.NoStoreRemote = true
+	default:
+		sc.Extensions = append(sc.Extensions, token)
+	}
+	return err
+}
+
+func (sc *SurrogateControlDirectives) addPair(token string, v string) error {
+	var err error = nil
+
+	switch token {
+	case "max-age":
+		if i := strings.IndexByte(v, '+'); i != -1 {
+			v = v[:i]
+		}
+		sc.MaxAge, err = parseDeltaSeconds(v)
+		if err != nil {
+			err = ErrMaxAgeDeltaSeconds
+		}
+	case "no-store":
+		err = ErrNoStoreNoArgs
+	case "no-store-remote":
+		err = ErrNoStoreNoArgs
+	default:
+		sc.Extensions = append(sc.Extensions, token+"="+v)
+	}
+
+	return err
+}
+
+// LOW LEVEL API: Merges the directives of a response for an edge cache, where
+// `Surrogate-Control` takes precedence over `Cache-Control`.
+//
+// A surrogate max-age replaces both max-age and s-maxage, and a surrogate no-store
+// or no-store-remote always prevents storing the response. Directives only present
+// in cc are kept. Neither cc nor sc are modified, and sc may be nil.
+//
+// The `Surrogate-Control` header should be stripped before the response is
+// forwarded downstream, since the merged directives only apply to the edge.
+func EffectiveEdgePolicy(cc *ResponseCacheDirectives, sc *SurrogateControlDirectives) *ResponseCacheDirectives {
+	merged := *cc
+
+	if sc == nil {
+		return &merged
+	}
+
+	if sc.MaxAge != -1 {
+		merged.MaxAge = sc.MaxAge
+		merged.SMaxAge = sc.MaxAge
+	}
+
+	if sc.NoStore || sc.NoStoreRemote {
+		merged.NoStore = true
+	}
+
+	return &merged
+}
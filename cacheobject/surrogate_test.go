@@ -0,0 +1,78 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cacheobject
+
+import (
+	"github.com/stretchr/testify/require"
+
+	"testing"
+)
+
+func TestParseSurrogateControl(t *testing.T) {
+	sc, err := ParseSurrogateControl(`max-age=60+30, no-store-remote, content="ESI/1.0"`)
+	require.NoError(t, err)
+	require.Equal(t, sc.MaxAge, DeltaSeconds(60))
+	require.False(t, sc.NoStore)
+	require.True(t, sc.NoStoreRemote)
+	require.Contains(t, sc.Extensions, "content=ESI/1.0")
+
+	_, err = ParseSurrogateControl(`max-age`)
+	require.Equal(t, err, ErrMaxAgeDeltaSeconds)
+}
+
+func TestEffectiveEdgePolicyMaxAge(t *testing.T) {
+	cc, err := ParseResponseCacheControl(`public, max-age=60`)
+	require.NoError(t, err)
+	sc, err := ParseSurrogateControl(`max-age=3600`)
+	require.NoError(t, err)
+
+	edge := EffectiveEdgePolicy(cc, sc)
+	require.Equal(t, edge.MaxAge, DeltaSeconds(3600))
+	require.Equal(t, edge.SMaxAge, DeltaSeconds(3600))
+	require.True(t, edge.Public)
+
+	// the Cache-Control directives are not modified
+	require.Equal(t, cc.MaxAge, DeltaSeconds(60))
+	require.Equal(t, cc.SMaxAge, DeltaSeconds(-1))
+}
+
+func TestEffectiveEdgePolicyNoStore(t *testing.T) {
+	cc, err := ParseResponseCacheControl(`public, max-age=60`)
+	require.NoError(t, err)
+	sc, err := ParseSurrogateControl(`no-store`)
+	require.NoError(t, err)
+
+	edge := EffectiveEdgePolicy(cc, sc)
+	require.True(t, edge.NoStore)
+	require.Equal(t, edge.MaxAge, DeltaSeconds(60))
+	require.False(t, cc.NoStore)
+}
+
+func TestEffectiveEdgePolicyNoSurrogate(t *testing.T) {
+	cc, err := ParseResponseCacheControl(`s-maxage=60`)
+	require.NoError(t, err)
+
+	edge := EffectiveEdgePolicy(cc, nil)
+	require.Equal(t, edge.SMaxAge, DeltaSeconds(60))
+	require.Equal(t, edge.MaxAge, DeltaSeconds(-1))
+}
+
+func TestSurrogateObsoleteLineFolding(t *testing.T) {
+	_, err := ParseSurrogateControl("max-age=60,\r\n no-store")
+	require.Equal(t, ErrObsoleteLineFolding, err)
+}
@@ -0,0 +1,41 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cacheobject
+
+import (
+	"net/http"
+)
+
+// LOW LEVEL API: Check if a response being stored while it is streamed must be discarded,
+// because its trailers include Cache-Control: no-store or private. Trailers can only be
+// seen once the whole body has been read: http://tools.ietf.org/html/rfc7230#section-4.1.2
+//
+// This is meant for shared caches, a private cache may keep a response with private.
+// A Cache-Control trailer which fails to parse is treated as no-store.
+func ShouldDiscardAfterTrailers(trailers http.Header) bool {
+	if len(trailers.Values("Cache-Control")) == 0 {
+		return false
+	}
+
+	cd, err := ParseResponseCacheControl(joinedHeader(trailers, "Cache-Control"))
+	if err != nil {
+		return true
+	}
+
+	return cd.NoStore || cd.PrivatePresent
+}
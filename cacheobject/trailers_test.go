@@ -0,0 +1,44 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cacheobject
+
+import (
+	"github.com/stretchr/testify/require"
+
+	"net/http"
+	"testing"
+)
+
+func TestShouldDiscardAfterTrailersNoStore(t *testing.T) {
+	require.True(t, ShouldDiscardAfterTrailers(http.Header{"Cache-Control": {"no-store"}}))
+}
+
+func TestShouldDiscardAfterTrailersPrivate(t *testing.T) {
+	require.True(t, ShouldDiscardAfterTrailers(http.Header{"Cache-Control": {"private"}}))
+	require.True(t, ShouldDiscardAfterTrailers(http.Header{"Cache-Control": {`private="Set-Cookie"`}}))
+}
+
+func TestShouldDiscardAfterTrailersNeither(t *testing.T) {
+	require.False(t, ShouldDiscardAfterTrailers(nil))
+	require.False(t, ShouldDiscardAfterTrailers(http.Header{"Grpc-Status": {"0"}}))
+	require.False(t, ShouldDiscardAfterTrailers(http.Header{"Cache-Control": {"max-age=60"}}))
+}
+
+func TestShouldDiscardAfterTrailersInvalid(t *testing.T) {
+	require.True(t, ShouldDiscardAfterTrailers(http.Header{"Cache-Control": {"max-age=foo"}}))
+}
@@ -0,0 +1,208 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cacheobject
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// LOW LEVEL API: Check if the `If-Range` header of a request matches the stored response,
+// in which case the requested range may be served, otherwise the full representation
+// must be sent: http://tools.ietf.org/html/rfc7233#section-3.2
+//
+// ifRange is either an entity-tag, which must strongly match respETag, or an HTTP-date,
+// which must exactly match respLastModified. An empty ifRange is always satisfied.
+func IfRangeSatisfied(ifRange string, respETag string, respLastModified time.Time) bool {
+	ifRange = strings.TrimSpace(ifRange)
+	if ifRange == "" {
+		return true
+	}
+
+	if strings.HasPrefix(ifRange, `"`) || strings.HasPrefix(ifRange, `W/"`) {
+		return strongETagMatch(ifRange, strings.TrimSpace(respETag))
+	}
+
+	date, err := http.ParseTime(ifRange)
+	if err != nil || respLastModified.IsZero() {
+		return false
+	}
+
+	return date.Equal(respLastModified)
+}
+
+// LOW LEVEL API: Check if a 206 Partial Content response is for the same representation
+// as a stored response, so their ranges may be combined: http://tools.ietf.org/html/rfc7234#section-3.3
+//
+// When either response has an ETag, they must strongly match. Otherwise both must have
+// the same Last-Modified date.
+func PartialMatchesStored(storedResp http.Header, partialResp http.Header) bool {
+	storedETag := strings.TrimSpace(storedResp.Get("ETag"))
+	partialETag := strings.TrimSpace(partialResp.Get("ETag"))
+	if storedETag != "" || partialETag != "" {
+		return strongETagMatch(storedETag, partialETag)
+	}
+
+	storedLastModified, err := http.ParseTime(storedResp.Get("Last-Modified"))
+	if err != nil {
+		return false
+	}
+
+	partialLastModified, err := http.ParseTime(partialResp.Get("Last-Modified"))
+	if err != nil {
+		return false
+	}
+
+	return storedLastModified.Equal(partialLastModified)
+}
+
+// LOW LEVEL API: Check if a stored response has a strong validator, which is required,
+// eg, to combine ranges or to satisfy an If-Range request: http://tools.ietf.org/html/rfc7232#section-2.1
+//
+// An ETag is strong unless it is marked weak with `W/`. A Last-Modified date is only
+// strong if it is at least 60 seconds before the response's Date: http://tools.ietf.org/html/rfc7232#section-2.2.2
+func HasUsableStrongValidator(respHeaders http.Header) bool {
+	etag := strings.TrimSpace(respHeaders.Get("ETag"))
+	if etag != "" && !isWeakETag(etag) {
+		return true
+	}
+
+	lastModified, err := parseHTTPDate(respHeaders.Get("Last-Modified"))
+	if err != nil {
+		return false
+	}
+
+	date, err := parseHTTPDate(respHeaders.Get("Date"))
+	if err != nil {
+		return false
+	}
+
+	return date.Sub(lastModified) >= time.Minute
+}
+
+// How a cache should handle a conditional request for a fresh stored response.
+type Disposition int
+
+const (
+	// Serve the stored response, the request's conditions don't prevent it.
+	DispositionServe Disposition = iota
+
+	// Respond with 304 Not Modified, the client already has the stored response.
+	DispositionNotModified
+
+	// Forward the request to the origin server, which must evaluate its conditions,
+	// eg, to respond with 412 Precondition Failed.
+	DispositionRevalidate
+)
+
+// LOW LEVEL API: Evaluate the conditional headers of a request against a fresh stored response,
+// in the order they take precedence: http://tools.ietf.org/html/rfc7232#section-6
+//
+// If-Match is satisfied by `*` or a strongly matching ETag, otherwise the origin must decide.
+// If-None-Match is satisfied, and the client already has the response, for `*` or a weakly
+// matching ETag. If-Modified-Since is only used without If-None-Match: http://tools.ietf.org/html/rfc7234#section-4.3.2
+func ConditionalRequestDisposition(reqHeaders http.Header, cachedResp http.Header) Disposition {
+	etag := strings.TrimSpace(cachedResp.Get("ETag"))
+
+	if ifMatch := reqHeaders.Get("If-Match"); ifMatch != "" {
+		if !etagListMatches(ifMatch, etag, strongETagMatch) {
+			return DispositionRevalidate
+		}
+	}
+
+	if ifNoneMatch := reqHeaders.Get("If-None-Match"); ifNoneMatch != "" {
+		if etagListMatches(ifNoneMatch, etag, weakETagMatch) {
+			return DispositionNotModified
+		}
+		return DispositionServe
+	}
+
+	if ifModifiedSince := reqHeaders.Get("If-Modified-Since"); ifModifiedSince != "" {
+		since, err := parseHTTPDate(ifModifiedSince)
+		if err != nil {
+			return DispositionServe
+		}
+
+		lastModified, err := parseHTTPDate(cachedResp.Get("Last-Modified"))
+		if err == nil && !lastModified.After(since) {
+			return DispositionNotModified
+		}
+	}
+
+	return DispositionServe
+}
+
+// check if an If-Match or If-None-Match list, which is either `*` or a list of entity-tags,
+// includes etag: http://tools.ietf.org/html/rfc7232#section-3.1
+func etagListMatches(list string, etag string, match func(a string, b string) bool) bool {
+	if strings.TrimSpace(list) == "*" {
+		return true
+	}
+
+	for _, candidate := range splitETags(list) {
+		if match(candidate, etag) {
+			return true
+		}
+	}
+	return false
+}
+
+// splits a list of entity-tags, which may contain commas inside their quotes.
+func splitETags(list string) []string {
+	var etags []string
+	start := 0
+	quoted := false
+	for i := 0; i < len(list); i++ {
+		switch list[i] {
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				etags = append(etags, strings.TrimSpace(list[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(etags, strings.TrimSpace(list[start:]))
+}
+
+// weak comparison of entity-tags: http://tools.ietf.org/html/rfc7232#section-2.3.2
+func weakETagMatch(a string, b string) bool {
+	return a != "" && strings.TrimPrefix(a, "W/") == strings.TrimPrefix(b, "W/")
+}
+
+// strong comparison of entity-tags: http://tools.ietf.org/html/rfc7232#section-2.3.2
+func strongETagMatch(a string, b string) bool {
+	if isWeakETag(a) || isWeakETag(b) {
+		return false
+	}
+
+	return a != "" && a == b
+}
+
+func isWeakETag(etag string) bool {
+	return strings.HasPrefix(etag, "W/")
+}
+
+// check if a stored response can be validated with a conditional request: http://tools.ietf.org/html/rfc7234#section-4.3.1
+func hasValidator(obj *Object) bool {
+	return obj.RespHeaders.Get("ETag") != "" ||
+		obj.RespHeaders.Get("Last-Modified") != "" ||
+		!obj.RespLastModifiedHeader.IsZero()
+}
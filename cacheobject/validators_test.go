@@ -0,0 +1,153 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cacheobject
+
+import (
+	"github.com/stretchr/testify/require"
+
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIfRangeETag(t *testing.T) {
+	require.True(t, IfRangeSatisfied(`"abc"`, `"abc"`, time.Time{}))
+	require.False(t, IfRangeSatisfied(`"abc"`, `"xyz"`, time.Time{}))
+	require.False(t, IfRangeSatisfied(`"abc"`, ``, time.Time{}))
+
+	// weak entity-tags never match for If-Range
+	require.False(t, IfRangeSatisfied(`W/"abc"`, `W/"abc"`, time.Time{}))
+	require.False(t, IfRangeSatisfied(`"abc"`, `W/"abc"`, time.Time{}))
+}
+
+func TestIfRangeDate(t *testing.T) {
+	lastModified := time.Date(2015, time.October, 21, 16, 29, 0, 0, time.UTC)
+
+	require.True(t, IfRangeSatisfied(lastModified.Format(http.TimeFormat), `"abc"`, lastModified))
+	require.False(t, IfRangeSatisfied(lastModified.Add(time.Second*-1).Format(http.TimeFormat), `"abc"`, lastModified))
+	require.False(t, IfRangeSatisfied(lastModified.Format(http.TimeFormat), `"abc"`, time.Time{}))
+	require.False(t, IfRangeSatisfied("not a date", `"abc"`, lastModified))
+}
+
+func TestIfRangeEmpty(t *testing.T) {
+	require.True(t, IfRangeSatisfied("", `"abc"`, time.Time{}))
+}
+
+func TestPartialMatchesStoredETag(t *testing.T) {
+	stored := http.Header{"Etag": {`"v1"`}}
+
+	require.True(t, PartialMatchesStored(stored, http.Header{"Etag": {`"v1"`}}))
+	require.False(t, PartialMatchesStored(stored, http.Header{"Etag": {`"v2"`}}))
+	require.False(t, PartialMatchesStored(stored, http.Header{}))
+	require.False(t, PartialMatchesStored(http.Header{"Etag": {`W/"v1"`}}, http.Header{"Etag": {`W/"v1"`}}))
+}
+
+func TestPartialMatchesStoredLastModified(t *testing.T) {
+	lastModified := time.Date(2015, time.October, 21, 16, 29, 0, 0, time.UTC)
+	stored := http.Header{"Last-Modified": {lastModified.Format(http.TimeFormat)}}
+
+	require.True(t, PartialMatchesStored(stored, http.Header{"Last-Modified": {lastModified.Format(http.TimeFormat)}}))
+	require.False(t, PartialMatchesStored(stored, http.Header{"Last-Modified": {lastModified.Add(time.Hour).Format(http.TimeFormat)}}))
+	require.False(t, PartialMatchesStored(stored, http.Header{}))
+	require.False(t, PartialMatchesStored(http.Header{}, http.Header{}))
+}
+
+func TestHasUsableStrongValidatorETag(t *testing.T) {
+	require.True(t, HasUsableStrongValidator(http.Header{"Etag": {`"v1"`}}))
+	require.False(t, HasUsableStrongValidator(http.Header{"Etag": {`W/"v1"`}}))
+	require.False(t, HasUsableStrongValidator(http.Header{}))
+}
+
+func TestHasUsableStrongValidatorLastModified(t *testing.T) {
+	date := time.Date(2015, time.October, 21, 16, 29, 0, 0, time.UTC)
+
+	// just before Date, the resource may have changed again within the same second
+	require.False(t, HasUsableStrongValidator(http.Header{
+		"Date":          {date.Format(http.TimeFormat)},
+		"Last-Modified": {date.Add(time.Second * -30).Format(http.TimeFormat)},
+	}))
+
+	require.True(t, HasUsableStrongValidator(http.Header{
+		"Date":          {date.Format(http.TimeFormat)},
+		"Last-Modified": {date.Add(time.Hour * -1).Format(http.TimeFormat)},
+	}))
+
+	// without a Date, the Last-Modified date can't be judged
+	require.False(t, HasUsableStrongValidator(http.Header{
+		"Last-Modified": {date.Add(time.Hour * -1).Format(http.TimeFormat)},
+	}))
+
+	// a weak ETag doesn't hide a strong Last-Modified
+	require.True(t, HasUsableStrongValidator(http.Header{
+		"Etag":          {`W/"v1"`},
+		"Date":          {date.Format(http.TimeFormat)},
+		"Last-Modified": {date.Add(time.Hour * -1).Format(http.TimeFormat)},
+	}))
+}
+
+func TestConditionalRequestDispositionIfNoneMatchStar(t *testing.T) {
+	cached := http.Header{"Etag": {`"v1"`}}
+
+	require.Equal(t, DispositionNotModified, ConditionalRequestDisposition(http.Header{"If-None-Match": {"*"}}, cached))
+	require.Equal(t, DispositionNotModified, ConditionalRequestDisposition(http.Header{"If-None-Match": {"*"}}, http.Header{}))
+}
+
+func TestConditionalRequestDispositionIfNoneMatch(t *testing.T) {
+	cached := http.Header{"Etag": {`"v1"`}}
+
+	require.Equal(t, DispositionNotModified, ConditionalRequestDisposition(http.Header{"If-None-Match": {`"v1"`}}, cached))
+	require.Equal(t, DispositionNotModified, ConditionalRequestDisposition(http.Header{"If-None-Match": {`"v0", "v1"`}}, cached))
+
+	// If-None-Match uses the weak comparison
+	require.Equal(t, DispositionNotModified, ConditionalRequestDisposition(http.Header{"If-None-Match": {`W/"v1"`}}, cached))
+
+	require.Equal(t, DispositionServe, ConditionalRequestDisposition(http.Header{"If-None-Match": {`"v2"`}}, cached))
+	require.Equal(t, DispositionServe, ConditionalRequestDisposition(http.Header{"If-None-Match": {`"v1"`}}, http.Header{}))
+}
+
+func TestConditionalRequestDispositionIfMatch(t *testing.T) {
+	cached := http.Header{"Etag": {`"v1"`}}
+
+	require.Equal(t, DispositionServe, ConditionalRequestDisposition(http.Header{"If-Match": {"*"}}, cached))
+	require.Equal(t, DispositionServe, ConditionalRequestDisposition(http.Header{"If-Match": {`"a,b", "v1"`}}, cached))
+	require.Equal(t, DispositionRevalidate, ConditionalRequestDisposition(http.Header{"If-Match": {`"v2"`}}, cached))
+
+	// If-Match uses the strong comparison
+	require.Equal(t, DispositionRevalidate, ConditionalRequestDisposition(http.Header{"If-Match": {`W/"v1"`}}, cached))
+}
+
+func TestConditionalRequestDispositionIfModifiedSince(t *testing.T) {
+	lastModified := time.Date(2015, time.October, 21, 16, 29, 0, 0, time.UTC)
+	cached := http.Header{"Last-Modified": {lastModified.Format(http.TimeFormat)}}
+
+	require.Equal(t, DispositionNotModified, ConditionalRequestDisposition(
+		http.Header{"If-Modified-Since": {lastModified.Format(http.TimeFormat)}}, cached))
+	require.Equal(t, DispositionServe, ConditionalRequestDisposition(
+		http.Header{"If-Modified-Since": {lastModified.Add(time.Hour * -1).Format(http.TimeFormat)}}, cached))
+
+	// If-None-Match takes precedence
+	cached.Set("ETag", `"v1"`)
+	require.Equal(t, DispositionServe, ConditionalRequestDisposition(http.Header{
+		"If-None-Match":     {`"v2"`},
+		"If-Modified-Since": {lastModified.Format(http.TimeFormat)},
+	}, cached))
+}
+
+func TestConditionalRequestDispositionUnconditional(t *testing.T) {
+	require.Equal(t, DispositionServe, ConditionalRequestDisposition(http.Header{}, http.Header{"Etag": {`"v1"`}}))
+}
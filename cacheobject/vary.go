@@ -0,0 +1,170 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cacheobject
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Returned by SecondaryKeyFields for `Vary: *`, the response varies on more than
+// request headers, and a stored response can't be selected for a new request.
+const VaryAll = "*"
+
+// Normalizes the values of a request header nominated by `Vary`, before they are compared
+// by VaryMatches, eg, so `gzip, br` matches `br,gzip`.
+type VaryNormalizer func(values []string) string
+
+// normalizers used by VaryMatches, by canonical header name. Headers without a normalizer
+// have their values combined, with whitespace collapsed.
+var (
+	varyNormalizersMu sync.RWMutex
+	varyNormalizers   = map[string]VaryNormalizer{
+		"Accept-Encoding": NormalizeAcceptEncoding,
+	}
+)
+
+// Registers the normalizer used by VaryMatches for a request header, eg, for an application's
+// own headers, replacing any normalizer already registered for it, like the one for
+// Accept-Encoding.
+//
+// It is meant to be called from an init function, before any responses are evaluated,
+// so that every evaluation uses the same normalizers.
+func RegisterVaryNormalizer(name string, normalizer VaryNormalizer) {
+	varyNormalizersMu.Lock()
+	defer varyNormalizersMu.Unlock()
+	varyNormalizers[http.CanonicalHeaderKey(name)] = normalizer
+}
+
+// LOW LEVEL API: Check if a new request matches the request a response was stored for,
+// for every request header nominated by the stored response's `Vary` header:
+// http://tools.ietf.org/html/rfc7234#section-4.1
+//
+// A `Vary: *` never matches. Repeated field names are only compared once, and header
+// values are normalized, see RegisterVaryNormalizer.
+func VaryMatches(respHeaders http.Header, storedReqHeaders http.Header, reqHeaders http.Header) bool {
+	fields, star := ParseVary(respHeaders)
+	if star {
+		return false
+	}
+
+	return fields.Matches(storedReqHeaders, reqHeaders)
+}
+
+// LOW LEVEL API: The request header field names nominated by the `Vary` header of a response,
+// lowercased, deduplicated and sorted, see SecondaryKeyFields.
+type VaryFields []string
+
+// LOW LEVEL API: Parses the `Vary` header of a response. Returns true for `Vary: *`, in which
+// case no request matches the response: http://tools.ietf.org/html/rfc7234#section-4.1
+func ParseVary(respHeaders http.Header) (VaryFields, bool) {
+	fields := SecondaryKeyFields(respHeaders)
+	if len(fields) == 1 && fields[0] == VaryAll {
+		return nil, true
+	}
+	return VaryFields(fields), false
+}
+
+// Check if two requests have the same normalized values for every field, see RegisterVaryNormalizer.
+func (vf VaryFields) Matches(reqA http.Header, reqB http.Header) bool {
+	for _, name := range vf {
+		if normalizedHeaderValue(reqA, name) != normalizedHeaderValue(reqB, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the secondary cache key of a request, so that requests which Match have the same key.
+func (vf VaryFields) Key(req http.Header) string {
+	parts := make([]string, 0, len(vf))
+	for _, name := range vf {
+		parts = append(parts, name+":"+normalizedHeaderValue(req, name))
+	}
+	return strings.Join(parts, "\n")
+}
+
+// combines the values of a header, and normalizes them: http://tools.ietf.org/html/rfc7234#section-4.1
+func normalizedHeaderValue(h http.Header, name string) string {
+	name = http.CanonicalHeaderKey(name)
+	values := h[name]
+	if len(values) == 0 {
+		return ""
+	}
+
+	varyNormalizersMu.RLock()
+	normalizer, ok := varyNormalizers[name]
+	varyNormalizersMu.RUnlock()
+	if ok {
+		return normalizer(values)
+	}
+
+	parts := make([]string, 0, len(values))
+	for _, v := range values {
+		for _, p := range strings.Split(v, ",") {
+			parts = append(parts, strings.Join(strings.Fields(p), " "))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// Normalizes `Accept-Encoding` values, which are case-insensitive and unordered:
+// http://tools.ietf.org/html/rfc7231#section-5.3.4
+func NormalizeAcceptEncoding(values []string) string {
+	codings := []string{}
+	for _, v := range values {
+		for _, coding := range strings.Split(v, ",") {
+			coding = strings.ToLower(strings.Join(strings.Fields(coding), ""))
+			if coding != "" {
+				codings = append(codings, coding)
+			}
+		}
+	}
+
+	sort.Strings(codings)
+	return strings.Join(codings, ",")
+}
+
+// LOW LEVEL API: Returns the request header field names nominated by the `Vary` header of
+// a response, which are part of the secondary cache key: http://tools.ietf.org/html/rfc7234#section-4.1
+//
+// Field names are lowercased, deduplicated and sorted. The result is empty without a
+// `Vary` header, and only VaryAll for `Vary: *`.
+func SecondaryKeyFields(respHeaders http.Header) []string {
+	seen := map[string]bool{}
+	fields := []string{}
+
+	for _, v := range respHeaders[http.CanonicalHeaderKey("Vary")] {
+		for _, name := range strings.Split(v, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" || seen[name] {
+				continue
+			}
+			if name == VaryAll {
+				return []string{VaryAll}
+			}
+			seen[name] = true
+			fields = append(fields, name)
+		}
+	}
+
+	sort.Strings(fields)
+	return fields
+}
@@ -0,0 +1,149 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cacheobject
+
+import (
+	"github.com/stretchr/testify/require"
+
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestVaryMatchesNoVary(t *testing.T) {
+	stored := http.Header{"Accept-Encoding": {"gzip"}}
+	req := http.Header{"Accept-Encoding": {"br"}}
+
+	require.True(t, VaryMatches(http.Header{}, stored, req))
+}
+
+func TestVaryMatches(t *testing.T) {
+	resp := http.Header{"Vary": {"accept-encoding, Accept-Language"}}
+	stored := http.Header{"Accept-Encoding": {"gzip,  deflate"}, "Accept-Language": {"en"}}
+
+	require.True(t, VaryMatches(resp, stored, http.Header{"Accept-Encoding": {"gzip, deflate"}, "Accept-Language": {"en"}}))
+	require.True(t, VaryMatches(resp, stored, http.Header{"Accept-Encoding": {"gzip", "deflate"}, "Accept-Language": {"en"}}))
+	require.False(t, VaryMatches(resp, stored, http.Header{"Accept-Encoding": {"br"}, "Accept-Language": {"en"}}))
+	require.False(t, VaryMatches(resp, stored, http.Header{"Accept-Encoding": {"gzip, deflate"}}))
+}
+
+func TestVaryMatchesStar(t *testing.T) {
+	resp := http.Header{"Vary": {"*"}}
+
+	require.False(t, VaryMatches(resp, http.Header{}, http.Header{}))
+}
+
+func TestSecondaryKeyFieldsNoVary(t *testing.T) {
+	require.Equal(t, []string{}, SecondaryKeyFields(http.Header{}))
+}
+
+func TestSecondaryKeyFields(t *testing.T) {
+	resp := http.Header{}
+	resp.Add("Vary", " accept-LANGUAGE ,Accept-Encoding")
+	resp.Add("Vary", "X-Device,\tuser-agent")
+
+	require.Equal(t,
+		[]string{"accept-encoding", "accept-language", "user-agent", "x-device"},
+		SecondaryKeyFields(resp))
+}
+
+func TestSecondaryKeyFieldsStar(t *testing.T) {
+	resp := http.Header{"Vary": {"Accept-Encoding, *"}}
+
+	require.Equal(t, []string{VaryAll}, SecondaryKeyFields(resp))
+}
+
+func TestVaryMatchesDuplicateFields(t *testing.T) {
+	resp := http.Header{"Vary": {"Accept, accept", "ACCEPT"}}
+	stored := http.Header{"Accept": {"text/html, application/json"}}
+
+	require.Equal(t, []string{"accept"}, SecondaryKeyFields(resp))
+	require.True(t, VaryMatches(resp, stored, http.Header{"Accept": {"text/html,  application/json"}}))
+	require.False(t, VaryMatches(resp, stored, http.Header{"Accept": {"application/json"}}))
+}
+
+func TestVaryMatchesAcceptEncodingNormalized(t *testing.T) {
+	resp := http.Header{"Vary": {"Accept-Encoding"}}
+	stored := http.Header{"Accept-Encoding": {"gzip, br;q=0.5"}}
+
+	require.True(t, VaryMatches(resp, stored, http.Header{"Accept-Encoding": {"BR; q=0.5,GZIP"}}))
+	require.False(t, VaryMatches(resp, stored, http.Header{"Accept-Encoding": {"gzip"}}))
+}
+
+func TestRegisterVaryNormalizer(t *testing.T) {
+	RegisterVaryNormalizer("x-device", func(values []string) string {
+		return strings.ToLower(strings.Join(values, ","))
+	})
+	defer func() {
+		varyNormalizersMu.Lock()
+		delete(varyNormalizers, "X-Device")
+		varyNormalizersMu.Unlock()
+	}()
+
+	resp := http.Header{"Vary": {"X-Device"}}
+	stored := http.Header{"X-Device": {"Mobile"}}
+
+	require.True(t, VaryMatches(resp, stored, http.Header{"X-Device": {"MOBILE"}}))
+	require.False(t, VaryMatches(resp, stored, http.Header{"X-Device": {"desktop"}}))
+}
+
+func TestNormalizeAcceptEncoding(t *testing.T) {
+	require.Equal(t, "br,deflate,gzip;q=0.8", NormalizeAcceptEncoding([]string{"gzip; q=0.8, Deflate", "br,"}))
+	require.Equal(t, "", NormalizeAcceptEncoding([]string{""}))
+}
+
+func TestParseVary(t *testing.T) {
+	fields, star := ParseVary(http.Header{"Vary": {"Accept-Language, accept-encoding"}})
+	require.False(t, star)
+	require.Equal(t, VaryFields{"accept-encoding", "accept-language"}, fields)
+
+	fields, star = ParseVary(http.Header{})
+	require.False(t, star)
+	require.Len(t, fields, 0)
+}
+
+func TestParseVaryStar(t *testing.T) {
+	fields, star := ParseVary(http.Header{"Vary": {"Accept-Encoding", "*"}})
+	require.True(t, star)
+	require.Nil(t, fields)
+}
+
+func TestVaryFieldsMatches(t *testing.T) {
+	fields, _ := ParseVary(http.Header{"Vary": {"Accept-Encoding, Accept-Language"}})
+
+	reqA := http.Header{"Accept-Encoding": {"gzip, br"}, "Accept-Language": {"en"}}
+	reqB := http.Header{"Accept-Encoding": {"br", "gzip"}, "Accept-Language": {"en"}, "Cookie": {"a=1"}}
+	reqC := http.Header{"Accept-Encoding": {"gzip, br"}, "Accept-Language": {"de"}}
+
+	require.True(t, fields.Matches(reqA, reqB))
+	require.False(t, fields.Matches(reqA, reqC))
+	require.True(t, VaryFields{}.Matches(reqA, reqC))
+}
+
+func TestVaryFieldsKey(t *testing.T) {
+	fields, _ := ParseVary(http.Header{"Vary": {"Accept-Encoding, Accept-Language"}})
+
+	reqA := http.Header{"Accept-Encoding": {"gzip, br"}, "Accept-Language": {"en"}}
+	reqB := http.Header{"Accept-Encoding": {"br", "gzip"}, "Accept-Language": {"en"}}
+	reqC := http.Header{"Accept-Encoding": {"gzip, br"}}
+
+	require.Equal(t, "accept-encoding:br,gzip\naccept-language:en", fields.Key(reqA))
+	require.Equal(t, fields.Key(reqA), fields.Key(reqB))
+	require.NotEqual(t, fields.Key(reqA), fields.Key(reqC))
+	require.Equal(t, "", VaryFields{}.Key(reqA))
+}
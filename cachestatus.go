@@ -0,0 +1,73 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cachecontrol
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Given the name of a cache, if a request was served from it, and the Decision for the
+// response, determine the value of a `Cache-Status` header: https://tools.ietf.org/html/rfc9211
+//
+// A hit includes the remaining freshness of the response as `ttl`, which is negative when a
+// stale response was served. A miss is forwarded with `fwd=stale` when the stored response
+// was stale, or `fwd=uri-miss` otherwise. d may be nil when nothing is known about the response.
+func CacheStatusHeader(cacheName string, hit bool, d *Decision) string {
+	parts := []string{cacheStatusName(cacheName)}
+
+	var expires time.Time
+	if d != nil {
+		expires = d.ExpirationTime
+	}
+	now := time.Now().UTC()
+
+	if hit {
+		parts = append(parts, "hit")
+		if !expires.IsZero() {
+			ttl := int64(expires.Sub(now) / time.Second)
+			parts = append(parts, "ttl="+strconv.FormatInt(ttl, 10))
+		}
+	} else if !expires.IsZero() && !expires.After(now) {
+		parts = append(parts, "fwd=stale")
+	} else {
+		parts = append(parts, "fwd=uri-miss")
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+// the cache name is a token if possible, or a quoted string: https://tools.ietf.org/html/rfc8941#section-3.3
+func cacheStatusName(name string) string {
+	token := name != ""
+	for i := 0; i < len(name) && token; i++ {
+		c := name[i]
+		alpha := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		if i == 0 {
+			token = alpha || c == '*'
+			continue
+		}
+		token = alpha || (c >= '0' && c <= '9') || strings.IndexByte("!#$%&'*+-.^_`|~:/", c) != -1
+	}
+	if token {
+		return name
+	}
+
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(name) + `"`
+}
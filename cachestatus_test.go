@@ -0,0 +1,56 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cachecontrol
+
+import (
+	"github.com/stretchr/testify/require"
+
+	"testing"
+	"time"
+)
+
+func TestCacheStatusHeaderHit(t *testing.T) {
+	d := &Decision{ExpirationTime: time.Now().UTC().Add(time.Second*300 + time.Millisecond*500)}
+
+	require.Equal(t, "ExampleCache; hit; ttl=300", CacheStatusHeader("ExampleCache", true, d))
+}
+
+func TestCacheStatusHeaderHitStale(t *testing.T) {
+	d := &Decision{ExpirationTime: time.Now().UTC().Add(time.Second*-30 - time.Millisecond*500)}
+
+	require.Equal(t, "ExampleCache; hit; ttl=-30", CacheStatusHeader("ExampleCache", true, d))
+}
+
+func TestCacheStatusHeaderMiss(t *testing.T) {
+	require.Equal(t, "ExampleCache; fwd=uri-miss", CacheStatusHeader("ExampleCache", false, nil))
+
+	d := &Decision{ExpirationTime: time.Now().UTC().Add(time.Minute)}
+	require.Equal(t, "ExampleCache; fwd=uri-miss", CacheStatusHeader("ExampleCache", false, d))
+}
+
+func TestCacheStatusHeaderMissStale(t *testing.T) {
+	d := &Decision{ExpirationTime: time.Now().UTC().Add(-time.Minute)}
+
+	require.Equal(t, "ExampleCache; fwd=stale", CacheStatusHeader("ExampleCache", false, d))
+}
+
+func TestCacheStatusHeaderQuotedName(t *testing.T) {
+	require.Equal(t, "cdn.example.com; hit", CacheStatusHeader("cdn.example.com", true, nil))
+	require.Equal(t, `"Example \"Cache\""; hit`, CacheStatusHeader(`Example "Cache"`, true, nil))
+	require.Equal(t, `"1cache"; hit`, CacheStatusHeader("1cache", true, nil))
+}
@@ -0,0 +1,135 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cachecontrol
+
+import (
+	"github.com/pquerna/cachecontrol/cacheobject"
+
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The result of evaluating a response, eg, with CachableResponse, so it can be
+// passed along with a request.
+type Decision struct {
+	// The reasons the response should not be cached, see CachableResponse.
+	Reasons []cacheobject.Reason
+
+	// Informational reasons, which don't prevent the response from being cached,
+	// see cacheobject.Reason.IsInfo.
+	InfoReasons []cacheobject.Reason
+
+	ExpirationTime time.Time
+
+	// The response header fields named by Cache-Control: no-cache="field-name", sorted.
+	// They must not be sent in a response to a subsequent request without revalidating it,
+	// by private and shared caches alike: http://tools.ietf.org/html/rfc7234#section-5.2.2.2
+	NoCacheFields []string
+}
+
+// Given an HTTP Request and Response, determine the Decision for the response.
+func Decide(req *http.Request, resp *http.Response, opts Options) (*Decision, error) {
+	rv, err := evaluate(req, resp.StatusCode, resp.Header, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var respDir *cacheobject.ResponseCacheDirectives
+	if opts.LenientDirectives {
+		respDir, err = cacheobject.ParseResponseCacheControlLenient(strings.Join(resp.Header.Values("Cache-Control"), ", "))
+	} else {
+		respDir, err = cacheobject.ResponseDirectives(resp)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]string, 0, len(respDir.NoCache))
+	for field := range respDir.NoCache {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	return &Decision{
+		Reasons:        rv.OutReasons,
+		InfoReasons:    rv.OutInfoReasons,
+		ExpirationTime: rv.OutExpirationTime,
+		NoCacheFields:  fields,
+	}, nil
+}
+
+// Given an HTTP Request and Response, determine the Decision for both a shared and a
+// private cache, regardless of opts.PrivateCache. This highlights directives whose effect
+// differs between them, like private and s-maxage.
+func CachableBothModes(req *http.Request, resp *http.Response, opts Options) (shared, private Decision, err error) {
+	opts.PrivateCache = false
+	sharedDecision, err := Decide(req, resp, opts)
+	if err != nil {
+		return Decision{}, Decision{}, err
+	}
+
+	opts.PrivateCache = true
+	privateDecision, err := Decide(req, resp, opts)
+	if err != nil {
+		return Decision{}, Decision{}, err
+	}
+
+	return *sharedDecision, *privateDecision, nil
+}
+
+// The result of evaluating a response for one tier, as returned by EvaluateTiers.
+type TierResult struct {
+	// The Decision for the tier, nil if Err is set.
+	Decision *Decision
+	Err      error
+}
+
+// Given an HTTP Request and Response, determine the Decision for each tier of a multi-tier
+// cache, eg, a browser cache, a shared edge cache and an origin shield, each with its own
+// Options. The results are in the same order as tiers.
+//
+// An error for one tier, eg, because its Options parse an invalid Cache-Control header
+// strictly, does not prevent the others from being evaluated.
+func EvaluateTiers(req *http.Request, resp *http.Response, tiers []Options) []TierResult {
+	results := make([]TierResult, len(tiers))
+	for i, opts := range tiers {
+		d, err := Decide(req, resp, opts)
+		results[i] = TierResult{
+			Decision: d,
+			Err:      err,
+		}
+	}
+	return results
+}
+
+type decisionKey struct{}
+
+// Returns a copy of ctx carrying the Decision, so middleware further down a chain
+// can use it without evaluating the response again.
+func WithDecision(ctx context.Context, d *Decision) context.Context {
+	return context.WithValue(ctx, decisionKey{}, d)
+}
+
+// Returns the Decision carried by ctx, if any.
+func DecisionFromContext(ctx context.Context) (*Decision, bool) {
+	d, ok := ctx.Value(decisionKey{}).(*Decision)
+	return d, ok && d != nil
+}
@@ -0,0 +1,203 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cachecontrol
+
+import (
+	"github.com/pquerna/cachecontrol/cacheobject"
+	"github.com/stretchr/testify/require"
+
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestDecisionContext(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+	}
+	res.Header.Set("Cache-Control", "no-store")
+
+	reasons, expires, err := CachableResponse(req, res, Options{})
+	require.NoError(t, err)
+
+	ctx := WithDecision(req.Context(), &Decision{Reasons: reasons, ExpirationTime: expires})
+
+	d, ok := DecisionFromContext(ctx)
+	require.True(t, ok)
+	require.Equal(t, []cacheobject.Reason{cacheobject.ReasonResponseNoStore}, d.Reasons)
+	require.Equal(t, expires, d.ExpirationTime)
+}
+
+func TestDecisionContextMissing(t *testing.T) {
+	d, ok := DecisionFromContext(context.Background())
+	require.False(t, ok)
+	require.Nil(t, d)
+
+	d, ok = DecisionFromContext(WithDecision(context.Background(), nil))
+	require.False(t, ok)
+	require.Nil(t, d)
+}
+
+func TestDecideNoCacheFields(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+	}
+	res.Header.Set("Cache-Control", `max-age=60, no-cache="set-cookie, X-Request-Id"`)
+
+	for _, privateCache := range []bool{false, true} {
+		d, err := Decide(req, res, Options{PrivateCache: privateCache})
+		require.NoError(t, err)
+		require.Len(t, d.Reasons, 0)
+		require.Equal(t, []string{"Set-Cookie", "X-Request-Id"}, d.NoCacheFields, "private cache: %v", privateCache)
+		require.False(t, d.ExpirationTime.IsZero())
+	}
+}
+
+func TestDecideNoNoCacheFields(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+	}
+	res.Header.Set("Cache-Control", "no-cache")
+
+	d, err := Decide(req, res, Options{})
+	require.NoError(t, err)
+	require.Len(t, d.NoCacheFields, 0)
+}
+
+func TestCachableBothModesPrivate(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+	}
+	res.Header.Set("Cache-Control", "private, max-age=60")
+
+	shared, private, err := CachableBothModes(req, res, Options{PrivateCache: true})
+	require.NoError(t, err)
+	require.Equal(t, []cacheobject.Reason{cacheobject.ReasonResponsePrivate}, shared.Reasons)
+	require.Len(t, private.Reasons, 0)
+	require.False(t, private.ExpirationTime.IsZero())
+}
+
+func TestCachableBothModesSMaxAge(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+	}
+	res.Header.Set("Cache-Control", "max-age=60, s-maxage=3600")
+
+	shared, private, err := CachableBothModes(req, res, Options{})
+	require.NoError(t, err)
+	require.Len(t, shared.Reasons, 0)
+	require.Len(t, private.Reasons, 0)
+	require.Equal(t, time.Hour-time.Minute, shared.ExpirationTime.Sub(private.ExpirationTime).Round(time.Second))
+}
+
+func TestCachableBothModesError(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+	}
+	res.Header.Set("Cache-Control", `no-cache="Set-Cookie`)
+
+	_, _, err = CachableBothModes(req, res, Options{})
+	require.Error(t, err)
+}
+
+func TestEvaluateTiers(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+	}
+	res.Header.Set("Cache-Control", "private, max-age=60")
+
+	browser := Options{PrivateCache: true}
+	edge := Options{}
+	shield := Options{MaxFreshnessLifetime: time.Second * 30}
+
+	results := EvaluateTiers(req, res, []Options{browser, edge, shield})
+	require.Len(t, results, 3)
+	for _, r := range results {
+		require.NoError(t, r.Err)
+	}
+	require.Len(t, results[0].Decision.Reasons, 0)
+	require.Equal(t, []cacheobject.Reason{cacheobject.ReasonResponsePrivate}, results[1].Decision.Reasons)
+	require.Equal(t, []cacheobject.Reason{cacheobject.ReasonResponsePrivate}, results[2].Decision.Reasons)
+	require.Equal(t, []cacheobject.Reason{cacheobject.ReasonResponseFreshnessCapped}, results[2].Decision.InfoReasons)
+}
+
+func TestEvaluateTiersError(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+	}
+	res.Header.Set("Cache-Control", `no-cache="Set-Cookie`)
+
+	results := EvaluateTiers(req, res, []Options{{PrivateCache: true}, {}})
+	require.Len(t, results, 2)
+	for _, r := range results {
+		require.Error(t, r.Err)
+		require.Nil(t, r.Decision)
+	}
+	require.Len(t, EvaluateTiers(req, res, nil), 0)
+}
+
+func TestEvaluateTiersLenientTier(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+	}
+	res.Header.Set("Cache-Control", "public, max-age=abc")
+
+	results := EvaluateTiers(req, res, []Options{{}, {LenientDirectives: true}})
+	require.Len(t, results, 2)
+	require.Error(t, results[0].Err)
+	require.Nil(t, results[0].Decision)
+	require.NoError(t, results[1].Err)
+	require.NotNil(t, results[1].Decision)
+}
@@ -0,0 +1,53 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cachecontrol
+
+import (
+	"github.com/pquerna/cachecontrol/cacheobject"
+)
+
+// Given the directives of a stored response, determine the `Cache-Control` header
+// a cache should send downstream, eg, to clients of a caching proxy.
+//
+// A shared cache folds `s-maxage` into `max-age`, because the freshness lifetime
+// it applied is the one its clients should use, unless the response's own `max-age`
+// is smaller. As s-maxage implies proxy-revalidate, `proxy-revalidate` is added:
+// http://tools.ietf.org/html/rfc7234#section-5.2.2.9
+//
+// Other directives, including `private`, are preserved. A private cache forwards the
+// directives unchanged. Returns an empty string for nil directives.
+func DownstreamCacheControl(respDir *cacheobject.ResponseCacheDirectives, opts Options) string {
+	if respDir == nil {
+		return ""
+	}
+
+	if opts.PrivateCache {
+		return respDir.String()
+	}
+
+	downstream := *respDir
+	if downstream.SMaxAge != -1 {
+		if downstream.MaxAge == -1 || downstream.SMaxAge < downstream.MaxAge {
+			downstream.MaxAge = downstream.SMaxAge
+		}
+		downstream.SMaxAge = -1
+		downstream.ProxyRevalidate = true
+	}
+
+	return downstream.String()
+}
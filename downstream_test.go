@@ -0,0 +1,60 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cachecontrol
+
+import (
+	"github.com/pquerna/cachecontrol/cacheobject"
+	"github.com/stretchr/testify/require"
+
+	"testing"
+)
+
+func TestDownstreamCacheControlSMaxAge(t *testing.T) {
+	respDir, err := cacheobject.ParseResponseCacheControl("public, max-age=3600, s-maxage=60")
+	require.NoError(t, err)
+
+	require.Equal(t, "public, proxy-revalidate, max-age=60", DownstreamCacheControl(respDir, Options{}))
+
+	// the stored directives are not modified
+	require.Equal(t, respDir.SMaxAge, cacheobject.DeltaSeconds(60))
+}
+
+func TestDownstreamCacheControlPrivate(t *testing.T) {
+	respDir, err := cacheobject.ParseResponseCacheControl(`private="Set-Cookie", s-maxage=60`)
+	require.NoError(t, err)
+
+	require.Equal(t, `private="Set-Cookie", proxy-revalidate, max-age=60`, DownstreamCacheControl(respDir, Options{}))
+}
+
+func TestDownstreamCacheControlPrivateCache(t *testing.T) {
+	respDir, err := cacheobject.ParseResponseCacheControl("s-maxage=60, no-transform")
+	require.NoError(t, err)
+
+	require.Equal(t, "no-transform, s-maxage=60", DownstreamCacheControl(respDir, Options{PrivateCache: true}))
+}
+
+func TestDownstreamCacheControlSmallerMaxAge(t *testing.T) {
+	respDir, err := cacheobject.ParseResponseCacheControl("max-age=30, s-maxage=600")
+	require.NoError(t, err)
+
+	require.Equal(t, "proxy-revalidate, max-age=30", DownstreamCacheControl(respDir, Options{}))
+}
+
+func TestDownstreamCacheControlNil(t *testing.T) {
+	require.Equal(t, "", DownstreamCacheControl(nil, Options{}))
+}
@@ -0,0 +1,140 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cachecontrol
+
+import (
+	"github.com/pquerna/cachecontrol/cacheobject"
+
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Given an HTTP Request and Response, explain if the response may be cached as a list of
+// human readable sentences, one for each reason, eg, for a command line tool.
+//
+// Returns a single sentence describing the error if the response can't be evaluated.
+func Explain(req *http.Request, resp *http.Response, opts Options) []string {
+	rv, err := evaluate(req, resp.StatusCode, resp.Header, opts)
+	if err != nil {
+		return []string{fmt.Sprintf("Error: %v.", err)}
+	}
+
+	explanations := make([]string, 0, len(rv.OutReasons)+len(rv.OutInfoReasons)+1)
+	for _, r := range rv.OutReasons {
+		explanations = append(explanations, explainReason(r, req, resp, opts))
+	}
+	for _, r := range rv.OutInfoReasons {
+		explanations = append(explanations, explainReason(r, req, resp, opts))
+	}
+
+	if len(rv.OutReasons) == 0 {
+		if rv.OutExpirationTime.IsZero() {
+			explanations = append(explanations, "Cacheable, but without a freshness lifetime, it must be revalidated before each use.")
+		} else {
+			lifetime := rv.OutExpirationTime.Sub(time.Now().UTC()).Round(time.Second)
+			explanations = append(explanations, fmt.Sprintf("Cacheable, expires in %v.", lifetime))
+		}
+	}
+
+	return explanations
+}
+
+func explainReason(r cacheobject.Reason, req *http.Request, resp *http.Response, opts Options) string {
+	switch r {
+	case cacheobject.ReasonRequestMethodPOST:
+		return "Not cacheable: the request method is POST and the response has no explicit freshness."
+	case cacheobject.ReasonRequestMethodPUT,
+		cacheobject.ReasonRequestMethodDELETE,
+		cacheobject.ReasonRequestMethodCONNECT,
+		cacheobject.ReasonRequestMethodTRACE:
+		return fmt.Sprintf("Not cacheable: responses to %s requests are never cached.", req.Method)
+	case cacheobject.ReasonRequestMethodOPTIONS:
+		return "Not cacheable: the request method is OPTIONS, which is not cached, or the response has no explicit freshness."
+	case cacheobject.ReasonRequestMethodUnknown:
+		if req == nil {
+			return "Not cacheable: there is no request, so its method is not known to be cachable."
+		}
+		return fmt.Sprintf("Not cacheable: the request method %s is not known to be cachable.", req.Method)
+	case cacheobject.ReasonRequestNoStore:
+		return "Not cacheable: request contains Cache-Control: no-store."
+	case cacheobject.ReasonRequestAuthorizationHeader:
+		return "Not cacheable: request contains an Authorization header, and the response contains none of Cache-Control: public, must-revalidate or s-maxage."
+	case cacheobject.ReasonResponseNoStore:
+		return "Not cacheable: response contains Cache-Control: no-store."
+	case cacheobject.ReasonResponsePrivate:
+		return "Not cacheable: response contains Cache-Control: private and this is a shared cache."
+	case cacheobject.ReasonResponseUncachableByDefault:
+		return fmt.Sprintf("Not cacheable: response status %d is not cachable by default, and the response has no explicit freshness.", resp.StatusCode)
+	case cacheobject.ReasonRequestOnlyIfCached:
+		return "Not cacheable: request contains Cache-Control: only-if-cached."
+	case cacheobject.ReasonResponseFreshnessCapped:
+		return fmt.Sprintf("Note: the freshness lifetime was capped to %v.", opts.MaxFreshnessLifetime)
+	case cacheobject.ReasonResponseClockSkew:
+		return fmt.Sprintf("Note: response Date header %q is in the future, the clocks of the origin and the cache may disagree.", resp.Header.Get("Date"))
+	case cacheobject.ReasonResponseNotModified:
+		return "Not cacheable: a 304 Not Modified response updates a stored response, instead of being stored."
+	case cacheobject.ReasonRequestContradictoryDirectives:
+		return "Not cacheable: request contains both Cache-Control: only-if-cached and no-cache."
+	case cacheobject.ReasonRequestUncachableHeader:
+		present := []string{}
+		for _, name := range opts.UncachableRequestHeaders {
+			if req != nil && req.Header.Get(name) != "" {
+				present = append(present, http.CanonicalHeaderKey(name))
+			}
+		}
+		return fmt.Sprintf("Not cacheable: request contains %s, which the cache treats as uncachable.", strings.Join(present, ", "))
+	case cacheobject.ReasonResponseInformational:
+		return fmt.Sprintf("Not cacheable: informational %d responses are never cached.", resp.StatusCode)
+	case cacheobject.ReasonResponsePragmaNoCache:
+		return "Note: response contains Pragma: no-cache, it must be revalidated before each use."
+	case cacheobject.ReasonResponseBodyTooLarge:
+		return fmt.Sprintf("Not cacheable: response Content-Length %s is larger than %d bytes.", resp.Header.Get("Content-Length"), opts.MaxCachableBodyBytes)
+	case cacheobject.ReasonResponsePOSTContentLocationMismatch:
+		return fmt.Sprintf("Not cacheable: the request method is POST and the response Content-Location %q doesn't match the request URI.", resp.Header.Get("Content-Location"))
+	case cacheobject.ReasonResponseCompressedWithoutVary:
+		return fmt.Sprintf("Not cacheable: response contains Content-Encoding: %s without Vary: Accept-Encoding and this is a shared cache.", resp.Header.Get("Content-Encoding"))
+	case cacheobject.ReasonResponseNoValidator:
+		return "Not cacheable: response contains neither an ETag nor a Last-Modified header."
+	case cacheobject.ReasonResponseTransformConflict:
+		return "Not cacheable: response status is 203 Non-Authoritative Information, but it contains Cache-Control: no-transform."
+	case cacheobject.ReasonResponseMaxAgeExpiresDisagree:
+		return fmt.Sprintf("Note: response max-age and Expires header %q disagree, max-age was used.", resp.Header.Get("Expires"))
+	case cacheobject.ReasonInfoPOSTCachableWithFreshness:
+		return "Note: the request method is POST, and the response has explicit freshness and a matching Content-Location."
+	case cacheobject.ReasonResponseHeuristicOnly:
+		return "Note: response has no explicit freshness, it is only cacheable with a heuristic freshness lifetime."
+	case cacheobject.ReasonResponseVaryCookie:
+		return "Not cacheable: response contains Vary: Cookie and this is a shared cache."
+	case cacheobject.ReasonResponseRangeUnsupported:
+		return "Not cacheable: response status is 206 Partial Content, but it contains Accept-Ranges: none."
+	case cacheobject.ReasonResponseMultipartRange:
+		return "Not cacheable: response status is 206 Partial Content with a multipart/byteranges body."
+	case cacheobject.ReasonResponseUnknownDirective:
+		return fmt.Sprintf("Note: response Cache-Control %q contains extension directives this cache doesn't implement, they were ignored.", resp.Header.Get("Cache-Control"))
+	case cacheobject.ReasonResponseLengthMismatch:
+		return fmt.Sprintf("Not cacheable: response body length doesn't match its Content-Length %s.", resp.Header.Get("Content-Length"))
+	case cacheobject.ReasonResponseInvalidDirectiveIgnored:
+		return fmt.Sprintf("Note: response Cache-Control %q contains directives with invalid values, they were ignored.", resp.Header.Get("Cache-Control"))
+	case cacheobject.ReasonResponseAlreadyStaleOnReceipt:
+		return fmt.Sprintf("Note: response Age %s exceeds its freshness lifetime, it must be revalidated before use.", resp.Header.Get("Age"))
+	}
+
+	return "Not cacheable: " + r.String() + "."
+}
@@ -0,0 +1,82 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cachecontrol
+
+import (
+	"github.com/stretchr/testify/require"
+
+	"net/http"
+	"testing"
+	"time"
+)
+
+func explainResponse(t *testing.T, cacheControl string, opts Options) []string {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+	}
+	res.Header.Set("Cache-Control", cacheControl)
+
+	return Explain(req, res, opts)
+}
+
+func TestExplainPrivate(t *testing.T) {
+	require.Equal(t, []string{
+		"Not cacheable: response contains Cache-Control: private and this is a shared cache.",
+	}, explainResponse(t, "private", Options{}))
+}
+
+func TestExplainNoStore(t *testing.T) {
+	require.Equal(t, []string{
+		"Not cacheable: response contains Cache-Control: no-store.",
+	}, explainResponse(t, "no-store, max-age=60", Options{}))
+}
+
+func TestExplainCachable(t *testing.T) {
+	require.Equal(t, []string{
+		"Cacheable, expires in 1m0s.",
+	}, explainResponse(t, "max-age=60", Options{}))
+}
+
+func TestExplainCachableInformational(t *testing.T) {
+	require.Equal(t, []string{
+		"Note: the freshness lifetime was capped to 30s.",
+		"Cacheable, expires in 30s.",
+	}, explainResponse(t, "max-age=60", Options{MaxFreshnessLifetime: 30 * time.Second}))
+}
+
+func TestExplainError(t *testing.T) {
+	require.Equal(t, []string{
+		"Error: Missing closing quote.",
+	}, explainResponse(t, `no-cache="Set-Cookie`, Options{}))
+}
+
+func TestExplainNilRequest(t *testing.T) {
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+	}
+	res.Header.Set("Cache-Control", "max-age=60")
+
+	require.Equal(t, []string{
+		"Not cacheable: there is no request, so its method is not known to be cachable.",
+	}, Explain(nil, res, Options{}))
+}
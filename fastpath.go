@@ -0,0 +1,48 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cachecontrol
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Given an HTTP Request, determine if a response to it is definitely not cachable, without
+// evaluating the response, eg, to skip the rest of a caching pipeline for obvious cases.
+//
+// Returns true for methods whose responses are never cachable, like PUT or DELETE, and
+// for requests with Cache-Control: no-store. POST and OPTIONS are not included, because
+// their responses may be cachable. Returns false when in doubt, eg, for an Authorization
+// header, which the response may explicitly allow.
+func DefinitelyNotCachable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodOptions:
+	default:
+		return true
+	}
+
+	for _, v := range req.Header.Values("Cache-Control") {
+		for _, directive := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(directive), "no-store") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,59 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cachecontrol
+
+import (
+	"github.com/stretchr/testify/require"
+
+	"net/http"
+	"testing"
+)
+
+func TestDefinitelyNotCachableMethods(t *testing.T) {
+	for _, method := range []string{"GET", "HEAD", "POST", "OPTIONS"} {
+		req, err := http.NewRequest(method, "http://example.com/", nil)
+		require.NoError(t, err)
+		require.False(t, DefinitelyNotCachable(req), method)
+	}
+
+	for _, method := range []string{"PUT", "DELETE", "CONNECT", "TRACE", "PATCH", "PROPFIND"} {
+		req, err := http.NewRequest(method, "http://example.com/", nil)
+		require.NoError(t, err)
+		require.True(t, DefinitelyNotCachable(req), method)
+	}
+}
+
+func TestDefinitelyNotCachableRequestDirectives(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+
+	req.Header.Set("Cache-Control", "no-cache, max-age=0")
+	require.False(t, DefinitelyNotCachable(req))
+
+	req.Header.Add("Cache-Control", "No-Store")
+	require.True(t, DefinitelyNotCachable(req))
+}
+
+func TestDefinitelyNotCachableAuthorization(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "bearer random")
+
+	// the response may still allow caching with public, must-revalidate or s-maxage
+	require.False(t, DefinitelyNotCachable(req))
+}
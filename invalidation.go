@@ -0,0 +1,75 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cachecontrol
+
+import (
+	"net/http"
+)
+
+// Given an HTTP Request and Response, determine the URIs of stored responses a cache
+// MUST invalidate: http://tools.ietf.org/html/rfc7234#section-4.4
+//
+// Responses to unsafe requests, like POST, PUT, or DELETE, with a non-error status code
+// invalidate the effective request URI, and the URIs in the Location and Content-Location
+// headers, when they have the same host. Returns nil if nothing must be invalidated.
+func InvalidationTargets(req *http.Request, resp *http.Response, opts Options) []string {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return nil
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 399 {
+		return nil
+	}
+
+	reqURL := effectiveURI(req, opts)
+	if reqURL == nil {
+		return nil
+	}
+
+	targets := []string{reqURL.String()}
+	for _, name := range []string{"Location", "Content-Location"} {
+		v := resp.Header.Get(name)
+		if v == "" {
+			continue
+		}
+
+		u, err := reqURL.Parse(v)
+		if err != nil || u.Host != reqURL.Host {
+			// prevents denial of service attacks on other hosts
+			continue
+		}
+
+		u.Fragment = ""
+		target := u.String()
+		if !containsString(targets, target) {
+			targets = append(targets, target)
+		}
+	}
+
+	return targets
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cachecontrol
+
+import (
+	"github.com/stretchr/testify/require"
+
+	"net/http"
+	"testing"
+)
+
+func TestInvalidationTargets(t *testing.T) {
+	req, err := http.NewRequest("POST", "http://example.com/items", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: http.StatusCreated,
+		Header:     http.Header{},
+	}
+	res.Header.Set("Location", "/items/1")
+	res.Header.Set("Content-Location", "http://other.example.com/items/1")
+
+	require.Equal(t, []string{
+		"http://example.com/items",
+		"http://example.com/items/1",
+	}, InvalidationTargets(req, res, Options{}))
+}
+
+func TestInvalidationTargetsSafeOrError(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/items", nil)
+	require.NoError(t, err)
+
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+	}
+	require.Nil(t, InvalidationTargets(req, res, Options{}))
+
+	req.Method = "DELETE"
+	res.StatusCode = http.StatusInternalServerError
+	require.Nil(t, InvalidationTargets(req, res, Options{}))
+}
+
+func TestInvalidationTargetsEffectiveURI(t *testing.T) {
+	// a server side request behind a reverse proxy
+	req, err := http.NewRequest("PUT", "/items/1", nil)
+	require.NoError(t, err)
+	req.Host = "backend:8080"
+	req.Header.Set("X-Forwarded-Host", "example.com")
+
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+	}
+
+	require.Equal(t, []string{"http://backend:8080/items/1"}, InvalidationTargets(req, res, Options{}))
+
+	opts := Options{EffectiveURI: forwardedURI}
+	require.Equal(t, []string{"https://example.com/items/1"}, InvalidationTargets(req, res, opts))
+}
+
+func forwardedURI(req *http.Request) string {
+	return "https://" + req.Header.Get("X-Forwarded-Host") + req.URL.RequestURI()
+}
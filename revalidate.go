@@ -0,0 +1,51 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cachecontrol
+
+import (
+	"github.com/pquerna/cachecontrol/cacheobject"
+
+	"time"
+)
+
+// Given the expiration time of a stored response and its directives, determine when
+// a cache should trigger a background revalidation, so the response is refreshed
+// before it can no longer be served.
+//
+// Without `stale-while-revalidate`, this is opts.RevalidationLeadTime before expiresAt.
+// With it, the stale response may still be served while it is being revalidated:
+// https://tools.ietf.org/html/rfc5861#section-3, so revalidation is triggered at
+// expiresAt, or earlier if the lead time doesn't fit in the window.
+//
+// Returns the zero time if expiresAt is zero, because the response is not fresh at all.
+func RevalidateAt(expiresAt time.Time, respDir *cacheobject.ResponseCacheDirectives, opts Options) time.Time {
+	if expiresAt.IsZero() {
+		return time.Time{}
+	}
+
+	servableUntil := expiresAt
+	if respDir.StaleWhileRevalidate != -1 {
+		servableUntil = expiresAt.Add(time.Second * time.Duration(respDir.StaleWhileRevalidate))
+	}
+
+	revalidateAt := servableUntil.Add(-opts.RevalidationLeadTime)
+	if revalidateAt.After(expiresAt) {
+		return expiresAt
+	}
+	return revalidateAt
+}
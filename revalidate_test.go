@@ -0,0 +1,59 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cachecontrol
+
+import (
+	"github.com/pquerna/cachecontrol/cacheobject"
+	"github.com/stretchr/testify/require"
+
+	"testing"
+	"time"
+)
+
+func TestRevalidateAt(t *testing.T) {
+	expiresAt := time.Now().UTC().Add(time.Hour)
+
+	respDir, err := cacheobject.ParseResponseCacheControl("max-age=3600")
+	require.NoError(t, err)
+
+	require.Equal(t, expiresAt, RevalidateAt(expiresAt, respDir, Options{}))
+	require.Equal(t, expiresAt.Add(-time.Minute),
+		RevalidateAt(expiresAt, respDir, Options{RevalidationLeadTime: time.Minute}))
+}
+
+func TestRevalidateAtStaleWhileRevalidate(t *testing.T) {
+	expiresAt := time.Now().UTC().Add(time.Hour)
+
+	respDir, err := cacheobject.ParseResponseCacheControl("max-age=3600, stale-while-revalidate=120")
+	require.NoError(t, err)
+
+	// the lead time fits in the stale-while-revalidate window
+	require.Equal(t, expiresAt,
+		RevalidateAt(expiresAt, respDir, Options{RevalidationLeadTime: time.Minute}))
+
+	// the lead time doesn't fit in the stale-while-revalidate window
+	require.Equal(t, expiresAt.Add(-time.Minute),
+		RevalidateAt(expiresAt, respDir, Options{RevalidationLeadTime: time.Minute * 3}))
+}
+
+func TestRevalidateAtNotFresh(t *testing.T) {
+	respDir, err := cacheobject.ParseResponseCacheControl("")
+	require.NoError(t, err)
+
+	require.True(t, RevalidateAt(time.Time{}, respDir, Options{RevalidationLeadTime: time.Minute}).IsZero())
+}
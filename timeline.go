@@ -0,0 +1,78 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cachecontrol
+
+import (
+	"github.com/pquerna/cachecontrol/cacheobject"
+
+	"net/http"
+	"time"
+)
+
+// Given a stored response, determine the full lifecycle a cache should follow for it:
+//
+//   - until fresh, the response may be served freely.
+//   - until staleWhileRevalidate, it may be served stale while it is revalidated in the
+//     background: https://tools.ietf.org/html/rfc5861#section-3
+//   - until staleIfError, it may be served stale if revalidating it fails:
+//     https://tools.ietf.org/html/rfc5861#section-4
+//
+// storedAt is when the response was received, and now when it is being evaluated, so the
+// time it resided in the cache is accounted for, like with cacheobject.UpdatedAge.
+//
+// When a directive is missing, or stale responses may not be served, eg, because of
+// must-revalidate, its time equals fresh. All times are zero if the response has no
+// freshness lifetime at all.
+func CacheTimeline(respDir *cacheobject.ResponseCacheDirectives,
+	respHeaders http.Header,
+	storedAt, now time.Time,
+	opts Options) (fresh, staleWhileRevalidate, staleIfError time.Time, err error) {
+	obj, err := cacheobject.ObjectFromDirectives(respDir, http.StatusOK, respHeaders, now)
+	if err != nil {
+		return time.Time{}, time.Time{}, time.Time{}, err
+	}
+
+	obj.RespAgeHeader = cacheobject.UpdatedAge(obj.RespAgeHeader, storedAt, now)
+	obj.CacheIsPrivate = opts.PrivateCache
+	obj.DefaultToUncachable = opts.DefaultToUncachable
+	obj.MaxFreshnessLifetime = opts.MaxFreshnessLifetime
+	obj.Trace = opts.Trace
+
+	rv := cacheobject.ObjectResults{}
+	cacheobject.ExpirationObject(obj, &rv)
+	if rv.OutErr != nil {
+		return time.Time{}, time.Time{}, time.Time{}, rv.OutErr
+	}
+
+	fresh = rv.OutExpirationTime
+	if fresh.IsZero() {
+		return time.Time{}, time.Time{}, time.Time{}, nil
+	}
+
+	staleWhileRevalidate = fresh
+	if respDir.StaleWhileRevalidate != -1 && cacheobject.MayServeStale(respDir, opts.PrivateCache) {
+		staleWhileRevalidate = fresh.Add(time.Second * time.Duration(respDir.StaleWhileRevalidate))
+	}
+
+	staleIfError = fresh
+	if respDir.StaleIfError != -1 && cacheobject.MayServeStale(respDir, opts.PrivateCache) {
+		staleIfError = fresh.Add(time.Second * time.Duration(respDir.StaleIfError))
+	}
+
+	return fresh, staleWhileRevalidate, staleIfError, nil
+}
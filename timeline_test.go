@@ -0,0 +1,116 @@
+/**
+ *  Copyright 2015 Paul Querna
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cachecontrol
+
+import (
+	"github.com/pquerna/cachecontrol/cacheobject"
+	"github.com/stretchr/testify/require"
+
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCacheTimeline(t *testing.T) {
+	storedAt := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	now := storedAt.Add(time.Minute * 10)
+
+	respDir, err := cacheobject.ParseResponseCacheControl("max-age=3600, stale-while-revalidate=60, stale-if-error=86400")
+	require.NoError(t, err)
+
+	respHeaders := http.Header{}
+	respHeaders.Set("Date", storedAt.Format(http.TimeFormat))
+
+	fresh, swr, sie, err := CacheTimeline(respDir, respHeaders, storedAt, now, Options{})
+	require.NoError(t, err)
+	require.Equal(t, storedAt.Add(time.Hour), fresh)
+	require.Equal(t, storedAt.Add(time.Hour+time.Minute), swr)
+	require.Equal(t, storedAt.Add(time.Hour+time.Hour*24), sie)
+}
+
+func TestCacheTimelineAge(t *testing.T) {
+	storedAt := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	now := storedAt.Add(time.Minute * 10)
+
+	respDir, err := cacheobject.ParseResponseCacheControl("max-age=3600, stale-while-revalidate=60")
+	require.NoError(t, err)
+
+	respHeaders := http.Header{}
+	respHeaders.Set("Age", "600")
+
+	fresh, swr, sie, err := CacheTimeline(respDir, respHeaders, storedAt, now, Options{})
+	require.NoError(t, err)
+	require.Equal(t, storedAt.Add(time.Minute*50), fresh)
+	require.Equal(t, storedAt.Add(time.Minute*51), swr)
+	require.Equal(t, fresh, sie)
+}
+
+func TestCacheTimelineMustRevalidate(t *testing.T) {
+	storedAt := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	respDir, err := cacheobject.ParseResponseCacheControl("max-age=3600, must-revalidate, stale-while-revalidate=60, stale-if-error=600")
+	require.NoError(t, err)
+
+	fresh, swr, sie, err := CacheTimeline(respDir, http.Header{}, storedAt, storedAt, Options{})
+	require.NoError(t, err)
+	require.Equal(t, storedAt.Add(time.Hour), fresh)
+	require.Equal(t, fresh, swr)
+	require.Equal(t, fresh, sie)
+}
+
+func TestCacheTimelineNotFresh(t *testing.T) {
+	storedAt := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	respDir, err := cacheobject.ParseResponseCacheControl("stale-while-revalidate=60, stale-if-error=600")
+	require.NoError(t, err)
+
+	fresh, swr, sie, err := CacheTimeline(respDir, http.Header{}, storedAt, storedAt, Options{})
+	require.NoError(t, err)
+	require.True(t, fresh.IsZero())
+	require.True(t, swr.IsZero())
+	require.True(t, sie.IsZero())
+}
+
+func TestCacheTimelineBadDate(t *testing.T) {
+	now := time.Now().UTC()
+
+	respDir, err := cacheobject.ParseResponseCacheControl("max-age=60")
+	require.NoError(t, err)
+
+	respHeaders := http.Header{}
+	respHeaders.Set("Date", "yesterday")
+
+	_, _, _, err = CacheTimeline(respDir, respHeaders, now, now, Options{})
+	require.Error(t, err)
+}
+
+func TestCacheTimelineLenientDirectives(t *testing.T) {
+	storedAt := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	respDir, err := cacheobject.ParseResponseCacheControlLenient("max-age='60', stale-if-error=600")
+	require.NoError(t, err)
+
+	respHeaders := http.Header{}
+	respHeaders.Set("Cache-Control", "max-age='60', stale-if-error=600")
+
+	fresh, swr, sie, err := CacheTimeline(respDir, respHeaders, storedAt, storedAt, Options{})
+	require.NoError(t, err)
+	require.Equal(t, storedAt.Add(time.Minute), fresh)
+	require.Equal(t, fresh, swr)
+	require.Equal(t, storedAt.Add(time.Minute*11), sie)
+}